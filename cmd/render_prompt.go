@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/isometry/yaketty/internal/dialogue"
+	"github.com/isometry/yaketty/internal/persona"
+)
+
+// renderedPrompt is the JSON form of render-prompt's output.
+type renderedPrompt struct {
+	Persona string         `json:"persona"`
+	Model   string         `json:"model"`
+	Turn    int            `json:"turn"`
+	System  []string       `json:"system"`
+	Options map[string]any `json:"options"`
+}
+
+func renderPromptCmd() *cobra.Command {
+	var (
+		ref    string
+		turn   int
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "render-prompt [config-file|scenario]",
+		Short: "Print the system prompts a persona will receive, without contacting a model",
+		Long: `Assemble the system messages and model options that a persona would be sent on a given turn, and print them.
+
+Turn 0 for the first persona includes the opening prompt. The periodic reminder appears on every twelfth turn.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		PreRunE:      Load,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unknown format %q: expected text or json", format)
+			}
+			if turn < 0 {
+				return fmt.Errorf("turn must not be negative: %d", turn)
+			}
+
+			chat, err := dialogue.NewDialogue(cmd.Context(), cfg)
+			if err != nil {
+				return err
+			}
+
+			botID, ok := chat.Lookup(ref)
+			if !ok {
+				return fmt.Errorf("unknown persona: %s", ref)
+			}
+
+			var p *persona.Persona
+			if botID == dialogue.Moderator {
+				p = &chat.Moderator.Persona
+			} else {
+				p = chat.Personas[botID]
+			}
+
+			rendered := renderedPrompt{
+				Persona: p.Name,
+				Model:   p.Model,
+				Turn:    turn,
+				System:  chat.SystemPrompts(botID, turn),
+				Options: p.Options.AsMap(),
+			}
+
+			if format == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(rendered)
+			}
+
+			fmt.Printf("# %s (%s), turn %d\n", rendered.Persona, rendered.Model, rendered.Turn)
+			for i, prompt := range rendered.System {
+				fmt.Printf("\n## system %d\n%s\n", i+1, prompt)
+			}
+			fmt.Printf("\n## options\n")
+			for _, key := range slices.Sorted(maps.Keys(rendered.Options)) {
+				fmt.Printf("%s: %v\n", key, rendered.Options[key])
+			}
+
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&ref, "seat", "1", "The persona whose prompts to render: a seat such as 1 or persona2, moderator, or a name")
+	flagSet.IntVar(&turn, "turn", 0, "The turn, counted in messages so far, to render the prompts for")
+	flagSet.StringVar(&format, "format", "text", "Output format: text or json")
+
+	return cmd
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"cmp"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/isometry/yaketty/internal/dialogue"
+	"github.com/isometry/yaketty/internal/options"
+	"github.com/isometry/yaketty/internal/output"
+	"github.com/isometry/yaketty/internal/transcript"
+)
+
+func resumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume [transcript]",
+		Short: "Continue a dialogue from a saved transcript",
+		Long: `Reload a transcript written with --save and continue the dialogue from whichever persona is due to speak next.
+
+The transcript is updated in place after every turn unless --save names a different file. Limits count only the further turns taken, and --backend, --record and --replay apply as they would to a new dialogue.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := transcript.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			if viper.IsSet("override.max_turns") {
+				t.Config.Limits.MaxTurns = viper.GetInt("override.max_turns")
+			}
+			if viper.IsSet("override.max_duration") {
+				t.Config.Limits.MaxDuration = viper.GetDuration("override.max_duration")
+			}
+			if viper.IsSet("override.max_tokens") {
+				t.Config.Limits.MaxTokens = viper.GetInt("override.max_tokens")
+			}
+			if viper.IsSet("stream") {
+				t.Config.Stream = viper.GetBool("stream")
+			}
+
+			if spec := viper.GetString("override.backend"); spec != "" {
+				backend, err := options.ParseBackend(spec)
+				if err != nil {
+					return err
+				}
+				t.Config.OverrideBackend(backend)
+			}
+
+			chat, err := dialogue.Resume(cmd.Context(), t)
+			if err != nil {
+				return err
+			}
+
+			if chat.Output, err = output.Open(viper.GetStringSlice("output"), os.Stdout); err != nil {
+				return err
+			}
+
+			chat.SavePath = cmp.Or(viper.GetString("save"), args[0])
+			chat.Status = viper.GetBool("status")
+
+			if err := attachCassette(chat); err != nil {
+				return err
+			}
+
+			attachConsole(chat, viper.GetBool("director"))
+
+			return chat.Start()
+		},
+	}
+}
@@ -3,12 +3,16 @@ package cmd
 import (
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/isometry/yaketty/internal/config"
+	"github.com/isometry/yaketty/internal/console"
 	"github.com/isometry/yaketty/internal/dialogue"
+	"github.com/isometry/yaketty/internal/output"
 )
 
 var (
@@ -22,7 +26,7 @@ func New(version string) *cobra.Command {
 		Use:   "yaketty [config-file|scenario]",
 		Args:  cobra.ExactArgs(1),
 		Short: "A CLI for driving conversational AI models",
-		Long: `Yaketty orchestrates conversational AI dialogues between two personas using the Ollama API.
+		Long: `Yaketty orchestrates conversational AI dialogues between two or more personas using the Ollama API.
 
 USAGE PATTERNS:
   # Load a config file from filesystem
@@ -37,6 +41,12 @@ USAGE PATTERNS:
   # Override personas while keeping everything else
   yaketty debate -1 biden -2 trump
 
+  # Play the second persona yourself
+  yaketty debate -1 obama -2 @human
+
+  # Seat a panel of three or more personas
+  yaketty sketch --persona carlin --persona williams --persona pryor
+
 The positional argument accepts:
   - File paths (contains / or .yaml): loaded as full config file
   - Scenario names (e.g., "debate"): loaded from scenarios/ library
@@ -48,7 +58,9 @@ Flags override specific parts of the loaded configuration.`,
 		SilenceUsage: true,
 	}
 
-	flagSet := rootCmd.Flags()
+	// Flags are shared with subcommands such as render-prompt, validate and
+	// resume, so that they see the same configuration as a dialogue would
+	flagSet := rootCmd.PersistentFlags()
 	flagSet.StringP("scenario", "s", "", "Override the scenario for the dialogue")
 	_ = viper.BindPFlag("scenario", flagSet.Lookup("scenario"))
 
@@ -58,14 +70,29 @@ Flags override specific parts of the loaded configuration.`,
 	flagSet.StringSliceP("prompts", "p", nil, "Additional system prompts for the dialogue")
 	_ = viper.BindPFlag("prompts", flagSet.Lookup("prompts"))
 
+	flagSet.StringArray("persona", nil, "Seat a persona in the dialogue (repeat for each participant, replacing the configured personas)")
+	_ = viper.BindPFlag("override.personas", flagSet.Lookup("persona"))
+
 	flagSet.StringP("persona1", "1", "", "Override the persona for the first bot")
-	_ = viper.BindPFlag("persona1.persona", flagSet.Lookup("persona1"))
+	_ = viper.BindPFlag("override.persona1", flagSet.Lookup("persona1"))
 
 	flagSet.StringP("persona2", "2", "", "Override the persona for the second bot")
-	_ = viper.BindPFlag("persona2.persona", flagSet.Lookup("persona2"))
+	_ = viper.BindPFlag("override.persona2", flagSet.Lookup("persona2"))
+
+	flagSet.StringArray("prompt1", nil, "Additional private system prompts for the first bot")
+	_ = viper.BindPFlag("override.prompt1", flagSet.Lookup("prompt1"))
+
+	flagSet.StringArray("prompt2", nil, "Additional private system prompts for the second bot")
+	_ = viper.BindPFlag("override.prompt2", flagSet.Lookup("prompt2"))
+
+	flagSet.IntSlice("interactive", nil, "Play the persona in this seat yourself, typing its lines at the terminal (or use @human as a persona)")
+	_ = viper.BindPFlag("override.interactive", flagSet.Lookup("interactive"))
+
+	flagSet.Bool("director", false, "Accept director commands such as /note, /topic, /pause and /stop from the terminal while the dialogue runs")
+	_ = viper.BindPFlag("director", flagSet.Lookup("director"))
 
 	flagSet.StringP("personas", "P", "personas", "The path to library personas")
-	_ = viper.BindPFlag("personas", flagSet.Lookup("personas"))
+	_ = viper.BindPFlag("persona_library", flagSet.Lookup("personas"))
 
 	flagSet.StringP("opening", "o", "", "Opening prompt for the first persona")
 	_ = viper.BindPFlag("opening", flagSet.Lookup("opening"))
@@ -73,8 +100,41 @@ Flags override specific parts of the loaded configuration.`,
 	flagSet.StringP("model", "m", "", "Override the model for both personas")
 	_ = viper.BindPFlag("model", flagSet.Lookup("model"))
 
+	flagSet.String("backend", "", "Override the backend for all personas, as type[=url] (e.g. openai=http://localhost:8080/v1)")
+	_ = viper.BindPFlag("override.backend", flagSet.Lookup("backend"))
+
 	flagSet.StringVarP(&path, "path", "c", ".", "The path to the configuration file")
 
+	flagSet.String("save", "", "Save the dialogue transcript to this file after every turn")
+	_ = viper.BindPFlag("save", flagSet.Lookup("save"))
+
+	flagSet.String("record", "", "Record every model request and response to this cassette file")
+	_ = viper.BindPFlag("record", flagSet.Lookup("record"))
+
+	flagSet.String("replay", "", "Serve model responses from this cassette file instead of a live backend")
+	_ = viper.BindPFlag("replay", flagSet.Lookup("replay"))
+
+	flagSet.Bool("stream", false, "Stream responses token by token as they are generated")
+	_ = viper.BindPFlag("stream", flagSet.Lookup("stream"))
+
+	flagSet.StringArray("output", []string{"text"}, "Output as format[=path], repeatable to write several at once: "+strings.Join(output.Formats, ", "))
+	_ = viper.BindPFlag("output", flagSet.Lookup("output"))
+
+	flagSet.Bool("status", false, "Show tokens, tokens per second, prompt size and latency on stderr after every turn")
+	_ = viper.BindPFlag("status", flagSet.Lookup("status"))
+
+	flagSet.Int("max-turns", 0, "end the dialogue after this many turns (0 for unlimited)")
+	_ = viper.BindPFlag("override.max_turns", flagSet.Lookup("max-turns"))
+
+	flagSet.Duration("max-duration", 0, "end the dialogue after this much time has elapsed (0 for unlimited)")
+	_ = viper.BindPFlag("override.max_duration", flagSet.Lookup("max-duration"))
+
+	flagSet.Int("max-tokens", 0, "end the dialogue after this many tokens have been generated (0 for unlimited)")
+	_ = viper.BindPFlag("override.max_tokens", flagSet.Lookup("max-tokens"))
+
+	flagSet.String("context-strategy", "", "How much history each request carries: full, sliding-window[=N] or summarise[=N], keeping the N most recent messages verbatim")
+	_ = viper.BindPFlag("override.context", flagSet.Lookup("context-strategy"))
+
 	flagSet.Int("context", 8192, "size of the context window used to generate the next token")
 	_ = viper.BindPFlag("options.num_ctx", flagSet.Lookup("context"))
 
@@ -104,6 +164,9 @@ Flags override specific parts of the loaded configuration.`,
 	rootCmd.AddCommand(listScenariosCmd())
 	rootCmd.AddCommand(showPersonaCmd())
 	rootCmd.AddCommand(showScenarioCmd())
+	rootCmd.AddCommand(resumeCmd())
+	rootCmd.AddCommand(renderPromptCmd())
+	rootCmd.AddCommand(validateCmd())
 
 	return rootCmd
 }
@@ -131,5 +194,56 @@ func Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if chat.Output, err = output.Open(viper.GetStringSlice("output"), os.Stdout); err != nil {
+		return err
+	}
+
+	chat.SavePath = viper.GetString("save")
+	chat.Title = titleOf(args[0])
+	chat.Status = viper.GetBool("status")
+
+	if replay := viper.GetString("replay"); replay != "" {
+		if err := chat.Replay(replay); err != nil {
+			return err
+		}
+	} else if record := viper.GetString("record"); record != "" {
+		chat.Record(record)
+	}
+
+	attachConsole(chat, viper.GetBool("director"))
+
 	return chat.Start()
 }
+
+// titleOf turns a config path or scenario name such as "museum-heist" into a
+// title such as "Museum Heist".
+func titleOf(name string) string {
+	words := strings.FieldsFunc(strings.TrimSuffix(filepath.Base(name), ".yaml"), func(r rune) bool {
+		return r == '-' || r == '_' || r == ' '
+	})
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// attachCassette replays the dialogue from a cassette, or records it to one,
+// as asked by --replay or --record.
+func attachCassette(chat *dialogue.Dialogue) error {
+	if replay := viper.GetString("replay"); replay != "" {
+		return chat.Replay(replay)
+	}
+	if record := viper.GetString("record"); record != "" {
+		chat.Record(record)
+	}
+	return nil
+}
+
+// attachConsole connects the terminal to the dialogue when a human persona
+// needs it or director commands are wanted. Director commands are always
+// available once the terminal is attached.
+func attachConsole(chat *dialogue.Dialogue, director bool) {
+	if director || chat.NeedsInput() {
+		chat.Input = chat.Direct(console.Lines(os.Stdin))
+	}
+}
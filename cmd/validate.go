@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/isometry/yaketty/internal/config"
+)
+
+func validateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [config-file|scenario]",
+		Short: "Check a config or scenario for mistakes",
+		Long: `Load a config or scenario as a dialogue would, reporting every problem found: unknown keys (with file and line), persona references missing from the library, empty scenario text, out-of-range model options, and more roles than personas.
+
+The same checks run before every dialogue; validate reports them without starting one. Flags such as -P, -S and -1 are applied as they would be for a dialogue.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := Load(cmd, args); err != nil {
+				var problems config.Problems
+				if !errors.As(err, &problems) {
+					return err
+				}
+				for _, problem := range problems {
+					fmt.Println(problem)
+				}
+				return fmt.Errorf("%s: %d problem(s) found", args[0], len(problems))
+			}
+
+			fmt.Printf("%s: ok\n", args[0])
+			return nil
+		},
+	}
+}
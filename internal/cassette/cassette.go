@@ -0,0 +1,105 @@
+package cassette
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+
+	"github.com/isometry/yaketty/internal/utils"
+)
+
+// Cassette is a recording of every chat request made during a dialogue and
+// the response it received, so the dialogue can be replayed offline.
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// Interaction is a single request/response pair, identified by the persona
+// that made the request and how many requests that persona had made before.
+type Interaction struct {
+	Persona  string   `yaml:"persona"`
+	Turn     int      `yaml:"turn"`
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+type Request struct {
+	Model    string         `yaml:"model"`
+	Messages []Message      `yaml:"messages"`
+	Options  map[string]any `yaml:"options,omitempty"`
+}
+
+type Message struct {
+	Role    string `yaml:"role"`
+	Content string `yaml:"content"`
+}
+
+type Response struct {
+	Model           string        `yaml:"model,omitempty"`
+	Content         string        `yaml:"content"`
+	DoneReason      string        `yaml:"done_reason,omitempty"`
+	PromptEvalCount int           `yaml:"prompt_eval_count,omitempty"`
+	EvalCount       int           `yaml:"eval_count,omitempty"`
+	TotalDuration   time.Duration `yaml:"total_duration,omitempty"`
+}
+
+// New starts an empty cassette that will be saved to filePath.
+func New(filePath string) *Cassette {
+	return &Cassette{path: filePath}
+}
+
+// Load reads a recorded cassette from filePath.
+func Load(filePath string) (*Cassette, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{path: filePath}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("error parsing cassette %s: %w", filePath, err)
+	}
+
+	return c, nil
+}
+
+// Find returns the interaction recorded for persona's turn.
+func (c *Cassette) Find(persona string, turn int) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, i := range c.Interactions {
+		if i.Persona == persona && i.Turn == turn {
+			return i, true
+		}
+	}
+
+	return Interaction{}, false
+}
+
+// Record appends an interaction and saves the cassette, so that a recording
+// survives the dialogue being interrupted.
+func (c *Cassette) Record(i Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Interactions = append(c.Interactions, i)
+
+	return c.save()
+}
+
+// save writes the cassette atomically; the caller must hold c.mu.
+func (c *Cassette) save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomic(c.path, data)
+}
@@ -19,17 +19,32 @@ import (
 	"github.com/isometry/yaketty/internal/scenario"
 )
 
+// The number of personas that can be seated in a single dialogue.
+const (
+	MinPersonas = 2
+	MaxPersonas = 8
+)
+
 type Config struct {
-	scenario.Scenario `mapstructure:",squash"`
-	ExtraPrompts      []string             `mapstructure:"prompts"`
-	Persona1          persona.Persona      `mapstructure:"persona1"`
-	Persona2          persona.Persona      `mapstructure:"persona2"`
-	Options           options.ModelOptions `mapstructure:"options"`
+	scenario.Scenario `mapstructure:",squash" yaml:",inline"`
+	ExtraPrompts      []string               `mapstructure:"prompts" yaml:"prompts,omitempty"`
+	Personas          []persona.Persona      `mapstructure:"personas" yaml:"personas"`
+	Persona1          persona.Persona        `mapstructure:"persona1" yaml:"persona1,omitempty"`
+	Persona2          persona.Persona        `mapstructure:"persona2" yaml:"persona2,omitempty"`
+	Options           options.ModelOptions   `mapstructure:"options" yaml:"options"`
+	Stream            bool                   `mapstructure:"stream" yaml:"stream"`
+	Backend           options.BackendOptions `mapstructure:"backend" yaml:"backend"`
+	Context           options.ContextOptions `mapstructure:"context" yaml:"context"`
+
+	// source is the file the config was loaded from, for reporting problems
+	source   string
+	problems Problems
 }
 
 func Load(path, name string) (*Config, error) {
 	var data []byte
 	var err error
+	var source string
 
 	// Check if name is a direct path (has separators or is absolute)
 	if library.IsDirectPath(name) {
@@ -48,6 +63,9 @@ func Load(path, name string) (*Config, error) {
 			slog.Error("failed to read config", "error", err)
 			return nil, err
 		}
+
+		source = viper.ConfigFileUsed()
+		data, _ = os.ReadFile(source)
 	} else {
 		// Scenario name - use library fallback (local first, then embedded)
 		scenarioName := name
@@ -56,6 +74,7 @@ func Load(path, name string) (*Config, error) {
 		}
 
 		scenarioPath := filepath.Join("scenarios", scenarioName)
+		source = scenarioPath
 		slog.Debug("loading config from scenario", "name", name, "path", scenarioPath)
 
 		data, err = library.ReadFileOrPath(scenarioPath)
@@ -81,6 +100,9 @@ func Load(path, name string) (*Config, error) {
 		return nil, err
 	}
 
+	config.source = source
+	config.checkKeys(source, data, configType, viperKeys...)
+
 	slog.Debug("config before defaults", slog.Any("config", config))
 
 	defaults.SetDefaults(&config)
@@ -88,7 +110,7 @@ func Load(path, name string) (*Config, error) {
 	slog.Debug("config after defaults", slog.Any("config", config))
 
 	scenarioLibrary := viper.GetString("scenarios")
-	personaLibrary := viper.GetString("personas")
+	personaLibrary := viper.GetString("persona_library")
 
 	// Load scenario file first (if scenario override is specified via flag, use that)
 	scenarioToLoad := cmp.Or[string](viper.GetString("scenario"), config.Scenario.Scenario)
@@ -100,6 +122,7 @@ func Load(path, name string) (*Config, error) {
 			slog.Warn("error loading scenario from path", slog.Any("error", err))
 			return nil, err
 		}
+		config.checkFile(scenarioToLoad, configType)
 	} else if scenarioToLoad != "" {
 		// Library reference - add .yaml if not present
 		scenarioName := scenarioToLoad
@@ -113,128 +136,62 @@ func Load(path, name string) (*Config, error) {
 				slog.Warn("error loading scenario", slog.Any("error", err))
 				return nil, err
 			}
+			config.checkFile(scenarioFilePath, configType)
 		} else if viper.GetString("scenario") != "" {
 			// Not a file, use as scenario text
 			config.Scenario.Scenario = scenarioToLoad
 		}
 	}
 
-	// Load persona files from config or scenario
-	if config.Persona1.Persona != "" {
-		if library.IsDirectPath(config.Persona1.Persona) {
-			// Direct path - use as-is
-			slog.Debug("loading persona1 from direct path", slog.String("path", config.Persona1.Persona))
-			if err := config.Persona1.LoadFromFile(config.Persona1.Persona); err != nil {
-				slog.Warn("error loading persona1 from path", slog.Any("error", err))
-				return nil, err
-			}
-		} else {
-			// Library reference - add .yaml if not present
-			personaName := config.Persona1.Persona
-			if !strings.HasSuffix(personaName, ".yaml") {
-				personaName = personaName + ".yaml"
-			}
-			filePathPersona1 := filepath.Join(personaLibrary, personaName)
-			if library.FileExists(personaLibrary, personaName) {
-				slog.Debug("loading persona1 from library", slog.String("persona", config.Persona1.Persona))
-				if err := config.Persona1.LoadFromFile(filePathPersona1); err != nil {
-					slog.Warn("error loading persona1", slog.Any("error", err))
-					return nil, err
-				}
-			}
+	config.SeatLegacyPersonas()
+
+	if config.Moderator != nil {
+		if err := config.resolvePersona(&config.Moderator.Persona, config.Moderator.Persona.Persona, personaLibrary, false); err != nil {
+			return nil, err
 		}
 	}
 
-	if config.Persona2.Persona != "" {
-		if library.IsDirectPath(config.Persona2.Persona) {
-			// Direct path - use as-is
-			slog.Debug("loading persona2 from direct path", slog.String("path", config.Persona2.Persona))
-			if err := config.Persona2.LoadFromFile(config.Persona2.Persona); err != nil {
-				slog.Warn("error loading persona2 from path", slog.Any("error", err))
-				return nil, err
-			}
-		} else {
-			// Library reference - add .yaml if not present
-			personaName := config.Persona2.Persona
-			if !strings.HasSuffix(personaName, ".yaml") {
-				personaName = personaName + ".yaml"
-			}
-			filePathPersona2 := filepath.Join(personaLibrary, personaName)
-			if library.FileExists(personaLibrary, personaName) {
-				slog.Debug("loading persona2 from library", slog.String("persona", config.Persona2.Persona))
-				if err := config.Persona2.LoadFromFile(filePathPersona2); err != nil {
-					slog.Warn("error loading persona2", slog.Any("error", err))
-					return nil, err
-				}
-			}
+	// Load persona files from config or scenario
+	for i := range config.Personas {
+		if err := config.resolvePersona(&config.Personas[i], config.Personas[i].Persona, personaLibrary, false); err != nil {
+			return nil, err
 		}
 	}
 
 	// Apply command-line overrides AFTER file loading
-	if viper.GetString("persona1.persona") != "" {
-		persona1Override := viper.GetString("persona1.persona")
-
-		if library.IsDirectPath(persona1Override) {
-			// Direct path - load it
-			slog.Debug("loading persona1 override from path", slog.String("path", persona1Override))
-			if err := config.Persona1.LoadFromFile(persona1Override); err != nil {
-				slog.Warn("error loading persona1 override from path", slog.Any("error", err))
+	if overrides := viper.GetStringSlice("override.personas"); len(overrides) > 0 {
+		config.Personas = make([]persona.Persona, len(overrides))
+		for i, override := range overrides {
+			defaults.SetDefaults(&config.Personas[i])
+			if err := config.resolvePersona(&config.Personas[i], override, personaLibrary, true); err != nil {
 				return nil, err
 			}
-		} else {
-			// Check if it's a library reference - add .yaml if not present
-			personaName := persona1Override
-			if !strings.HasSuffix(personaName, ".yaml") {
-				personaName = personaName + ".yaml"
-			}
-			persona1FilePath := filepath.Join(personaLibrary, personaName)
-			if library.FileExists(personaLibrary, personaName) {
-				// Library file - load it
-				slog.Debug("loading persona1 override from library", slog.String("persona", persona1Override))
-				if err := config.Persona1.LoadFromFile(persona1FilePath); err != nil {
-					slog.Warn("error loading persona1 override", slog.Any("error", err))
-					return nil, err
-				}
-			} else {
-				// Library reference not found - return error
-				availablePersonas, _ := library.ListPersonas()
-				return nil, fmt.Errorf("persona not found in library: %s (available personas: %v)", persona1Override, availablePersonas)
-			}
 		}
 	}
 
-	if viper.GetString("persona2.persona") != "" {
-		persona2Override := viper.GetString("persona2.persona")
+	if n := len(config.Personas); n < MinPersonas || n > MaxPersonas {
+		return nil, fmt.Errorf("a dialogue needs between %d and %d personas, got %d", MinPersonas, MaxPersonas, n)
+	}
 
-		if library.IsDirectPath(persona2Override) {
-			// Direct path - load it
-			slog.Debug("loading persona2 override from path", slog.String("path", persona2Override))
-			if err := config.Persona2.LoadFromFile(persona2Override); err != nil {
-				slog.Warn("error loading persona2 override from path", slog.Any("error", err))
+	for i, key := range []string{"override.persona1", "override.persona2"} {
+		if override := viper.GetString(key); override != "" {
+			if err := config.resolvePersona(&config.Personas[i], override, personaLibrary, true); err != nil {
 				return nil, err
 			}
-		} else {
-			// Check if it's a library reference - add .yaml if not present
-			personaName := persona2Override
-			if !strings.HasSuffix(personaName, ".yaml") {
-				personaName = personaName + ".yaml"
-			}
-			persona2FilePath := filepath.Join(personaLibrary, personaName)
-			if library.FileExists(personaLibrary, personaName) {
-				// Library file - load it
-				slog.Debug("loading persona2 override from library", slog.String("persona", persona2Override))
-				if err := config.Persona2.LoadFromFile(persona2FilePath); err != nil {
-					slog.Warn("error loading persona2 override", slog.Any("error", err))
-					return nil, err
-				}
-			} else {
-				// Library reference not found - return error
-				availablePersonas, _ := library.ListPersonas()
-				return nil, fmt.Errorf("persona not found in library: %s (available personas: %v)", persona2Override, availablePersonas)
-			}
 		}
 	}
 
+	for i, key := range []string{"override.prompt1", "override.prompt2"} {
+		config.Personas[i].Prompts = append(config.Personas[i].Prompts, viper.GetStringSlice(key)...)
+	}
+
+	for _, seat := range viper.GetIntSlice("override.interactive") {
+		if seat < 1 || seat > len(config.Personas) {
+			return nil, fmt.Errorf("interactive seat out of range: %d (have %d personas)", seat, len(config.Personas))
+		}
+		config.Personas[seat-1].Human = true
+	}
+
 	// Apply other command-line overrides
 	if viper.GetString("opening") != "" {
 		config.OpeningPrompt = viper.GetString("opening")
@@ -244,26 +201,167 @@ func Load(path, name string) (*Config, error) {
 		config.ExtraPrompts = append(config.ExtraPrompts, viper.GetStringSlice("prompts")...)
 	}
 
-	// set default names
-	config.Persona1.Name = cmp.Or[string](config.Persona1.Name, "Jane")
-	config.Persona2.Name = cmp.Or[string](config.Persona2.Name, "John")
+	// Limits given on the command line replace the scenario's, even with 0 to lift one
+	if viper.IsSet("override.max_turns") {
+		config.Limits.MaxTurns = viper.GetInt("override.max_turns")
+	}
 
-	// merge shared options into persona options
-	if err := mergo.Merge(&config.Persona1.Options, &config.Options); err != nil {
-		return nil, err
+	if viper.IsSet("override.max_duration") {
+		config.Limits.MaxDuration = viper.GetDuration("override.max_duration")
 	}
 
-	if err := mergo.Merge(&config.Persona2.Options, &config.Options); err != nil {
-		return nil, err
+	if viper.IsSet("override.max_tokens") {
+		config.Limits.MaxTokens = viper.GetInt("override.max_tokens")
+	}
+
+	for i := range config.Personas {
+		p := &config.Personas[i]
+
+		defaults.SetDefaults(p)
+
+		// set default names
+		p.Name = cmp.Or(p.Name, defaultName(i))
+
+		// merge shared options into persona options
+		if err := mergo.Merge(&p.Options, &config.Options); err != nil {
+			return nil, err
+		}
+
+		// personas without a backend of their own use the shared one
+		if p.Backend == (options.BackendOptions{}) {
+			p.Backend = config.Backend
+		}
+	}
+
+	if m := config.Moderator; m != nil {
+		defaults.SetDefaults(&m.Persona)
+		m.Name = cmp.Or(m.Name, "Narrator")
+		if err := mergo.Merge(&m.Options, &config.Options); err != nil {
+			return nil, err
+		}
+		if m.Backend == (options.BackendOptions{}) {
+			m.Backend = config.Backend
+		}
 	}
 
 	// Apply global model override LAST
 	if viper.GetString("model") != "" {
 		globalModel := viper.GetString("model")
 		slog.Debug("applying global model override", slog.String("model", globalModel))
-		config.Persona1.Model = globalModel
-		config.Persona2.Model = globalModel
+		for i := range config.Personas {
+			config.Personas[i].Model = globalModel
+		}
+		if config.Moderator != nil {
+			config.Moderator.Model = globalModel
+		}
+	}
+
+	if viper.GetString("override.context") != "" {
+		contextOptions, err := options.ParseContext(viper.GetString("override.context"))
+		if err != nil {
+			return nil, err
+		}
+		config.Context.Strategy, config.Context.Window = contextOptions.Strategy, contextOptions.Window
+	}
+
+	// Apply global backend override alongside it
+	if viper.GetString("override.backend") != "" {
+		globalBackend, err := options.ParseBackend(viper.GetString("override.backend"))
+		if err != nil {
+			return nil, err
+		}
+		slog.Debug("applying global backend override", slog.Any("backend", globalBackend))
+		config.OverrideBackend(globalBackend)
+	}
+
+	if problems := config.validate(); len(problems) > 0 {
+		return nil, problems
 	}
 
 	return &config, nil
 }
+
+// defaultName names the persona in the given seat when its definition doesn't.
+func defaultName(seat int) string {
+	switch seat {
+	case 0:
+		return "Jane"
+	case 1:
+		return "John"
+	}
+	return fmt.Sprintf("Persona %d", seat+1)
+}
+
+// resolvePersona loads the persona definition referenced by ref into p.
+// A ref containing a path separator is read directly; otherwise it is looked
+// up in the persona library. When strict is false, a ref that doesn't name a
+// library persona is left alone as inline persona text.
+func (c *Config) resolvePersona(p *persona.Persona, ref, personaLibrary string, strict bool) error {
+	if ref == "" {
+		return nil
+	}
+
+	if ref == persona.HumanRef {
+		slog.Debug("seating human persona")
+		*p = persona.Persona{Name: "You", Human: true}
+		return nil
+	}
+
+	if library.IsDirectPath(ref) {
+		// Direct path - use as-is
+		slog.Debug("loading persona from direct path", slog.String("path", ref))
+		if err := p.LoadFromFile(ref); err != nil {
+			slog.Warn("error loading persona from path", slog.Any("error", err))
+			return err
+		}
+		c.checkFile(ref, personaType)
+		return nil
+	}
+
+	// Library reference - add .yaml if not present
+	personaName := ref
+	if !strings.HasSuffix(personaName, ".yaml") {
+		personaName = personaName + ".yaml"
+	}
+
+	if !library.FileExists(personaLibrary, personaName) {
+		if strict {
+			// Library reference not found - return error
+			availablePersonas, _ := library.ListPersonas()
+			return fmt.Errorf("persona not found in library: %s (available personas: %v)", ref, availablePersonas)
+		}
+		c.unresolved(ref)
+		return nil
+	}
+
+	slog.Debug("loading persona from library", slog.String("persona", ref))
+	personaPath := filepath.Join(personaLibrary, personaName)
+	if err := p.LoadFromFile(personaPath); err != nil {
+		slog.Warn("error loading persona", slog.Any("error", err))
+		return err
+	}
+	c.checkFile(personaPath, personaType)
+
+	return nil
+}
+
+// SeatLegacyPersonas moves the legacy persona1/persona2 definitions into
+// Personas, unless an explicit personas list was given.
+func (c *Config) SeatLegacyPersonas() {
+	if len(c.Personas) == 0 {
+		c.Personas = []persona.Persona{c.Persona1, c.Persona2}
+	}
+	c.Persona1, c.Persona2 = persona.Persona{}, persona.Persona{}
+}
+
+// OverrideBackend serves every persona, and the moderator, from backend.
+func (c *Config) OverrideBackend(backend options.BackendOptions) {
+	c.SeatLegacyPersonas()
+	c.Backend = backend
+	for i := range c.Personas {
+		c.Personas[i].Backend = backend
+	}
+	if c.Moderator != nil {
+		c.Moderator.Backend = backend
+	}
+}
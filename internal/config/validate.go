@@ -0,0 +1,211 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+
+	"github.com/isometry/yaketty/internal/library"
+	"github.com/isometry/yaketty/internal/options"
+	"github.com/isometry/yaketty/internal/persona"
+)
+
+// viperKeys are top-level config keys read directly through viper rather
+// than unmarshalled into Config.
+var viperKeys = []string{"model", "scenarios", "persona_library", "save", "record", "replay", "director", "status", "output"}
+
+// The types that files are checked against. Scenario files double as
+// configs, so are checked as configs.
+var (
+	configType  = reflect.TypeFor[Config]()
+	personaType = reflect.TypeFor[persona.Persona]()
+)
+
+// Problem is a single mistake found in a configuration.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	switch {
+	case p.File == "":
+		return p.Message
+	case p.Line == 0:
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// Problems is the error returned when a configuration fails validation.
+type Problems []Problem
+
+func (p Problems) Error() string {
+	lines := make([]string, len(p))
+	for i, problem := range p {
+		lines[i] = problem.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkFile reports keys in the YAML file at filePath that don't belong to t.
+func (c *Config) checkFile(filePath string, t reflect.Type) {
+	data, err := library.ReadFileOrPath(filePath)
+	if err != nil {
+		return
+	}
+	c.checkKeys(filePath, data, t)
+}
+
+// checkKeys reports keys in data that don't belong to t, other than allowed
+// top-level keys.
+func (c *Config) checkKeys(file string, data []byte, t reflect.Type, allowed ...string) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil || len(document.Content) == 0 {
+		// parse errors are reported when the file is loaded
+		return
+	}
+	c.problems = append(c.problems, unknownKeys(file, document.Content[0], t, "", allowed)...)
+}
+
+// unknownKeys walks node alongside t, reporting every mapping key that has no
+// matching yaml field.
+func unknownKeys(file string, node *yaml.Node, t reflect.Type, path string, allowed []string) []Problem {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var problems []Problem
+
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				if !slices.Contains(allowed, key.Value) {
+					problems = append(problems, Problem{File: file, Line: key.Line, Message: fmt.Sprintf("unknown key %q", path+key.Value)})
+				}
+				continue
+			}
+			problems = append(problems, unknownKeys(file, value, field, path+key.Value+".", nil)...)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			problems = append(problems, unknownKeys(file, item, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i), nil)...)
+		}
+	}
+
+	return problems
+}
+
+// yamlFields maps the yaml keys of struct type t to their field types,
+// flattening inline structs.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, flags, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+			continue
+		case strings.Contains(flags, "inline"):
+			for key, fieldType := range yamlFields(field.Type) {
+				fields[key] = fieldType
+			}
+		default:
+			fields[cmp.Or(name, strings.ToLower(field.Name))] = field.Type
+		}
+	}
+	return fields
+}
+
+// unresolved records a persona reference that names nothing in the library.
+// References are single words; anything longer is taken as inline persona
+// text.
+func (c *Config) unresolved(ref string) {
+	if strings.ContainsAny(ref, " \t\n") {
+		return
+	}
+	c.problems = append(c.problems, Problem{
+		File:    c.source,
+		Message: fmt.Sprintf("persona %q not found in library (inline personas should describe the character)", ref),
+	})
+}
+
+// validate checks the fully loaded configuration, returning every problem
+// found while loading it along with any it can see now.
+func (c *Config) validate() Problems {
+	problems := c.problems
+
+	add := func(format string, args ...any) {
+		problems = append(problems, Problem{File: c.source, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(c.Scenario.Scenario) == "" {
+		add("scenario text is empty")
+	}
+
+	// Personas seated beyond the scenario's roles simply play without one
+	switch n := len(c.Roles); {
+	case n > len(c.Personas):
+		add("scenario defines %d roles for %d personas", n, len(c.Personas))
+	case n > 0 && n < len(c.Personas):
+		slog.Warn("some personas have no scenario role", slog.Int("roles", n), slog.Int("personas", len(c.Personas)))
+	}
+
+	if n := len(c.Secrets); n > len(c.Personas) {
+		add("scenario defines %d secrets for %d personas", n, len(c.Personas))
+	}
+
+	if !slices.Contains(options.ContextStrategies, c.Context.Strategy) {
+		add("unknown context strategy %q (expected one of %s)", c.Context.Strategy, strings.Join(options.ContextStrategies, ", "))
+	}
+
+	if c.Context.Window < 0 {
+		add("context window must not be negative: %d", c.Context.Window)
+	}
+
+	for _, message := range optionProblems(c.Options) {
+		add("options: %s", message)
+	}
+
+	for i := range c.Personas {
+		for _, message := range optionProblems(c.Personas[i].Options) {
+			add("%s: %s", c.Personas[i].Name, message)
+		}
+		if c.Personas[i].WordsPerMinute < 0 {
+			add("%s: wpm must not be negative: %d", c.Personas[i].Name, c.Personas[i].WordsPerMinute)
+		}
+	}
+
+	if c.Moderator != nil {
+		for _, message := range optionProblems(c.Moderator.Options) {
+			add("%s: %s", c.Moderator.Name, message)
+		}
+	}
+
+	return problems
+}
+
+// optionProblems describes any model options outside their valid range.
+func optionProblems(o options.ModelOptions) []string {
+	var messages []string
+	if o.Temperature < 0 || o.Temperature > 2 {
+		messages = append(messages, fmt.Sprintf("temperature %g is outside the range 0 to 2", o.Temperature))
+	}
+	if o.TopP < 0 || o.TopP > 1 {
+		messages = append(messages, fmt.Sprintf("top_p %g is outside the range 0 to 1", o.TopP))
+	}
+	return messages
+}
@@ -0,0 +1,22 @@
+package console
+
+import (
+	"bufio"
+	"io"
+)
+
+// Lines reads r line by line in the background, delivering each line on the
+// returned channel, which is closed when r is exhausted.
+func Lines(r io.Reader) <-chan string {
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	return lines
+}
@@ -0,0 +1,155 @@
+package dialogue
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/options"
+)
+
+// Backend sends chat requests to a model server. When the request asks to
+// stream, fn is called with each chunk as it arrives; otherwise it is called
+// once with the whole message. Either way, the final call has Done set and
+// carries the response metrics.
+type Backend interface {
+	Chat(ctx context.Context, req *api.ChatRequest, fn api.ChatResponseFunc) error
+}
+
+// NewBackend connects to the model server described by opts.
+func NewBackend(opts options.BackendOptions) (Backend, error) {
+	switch cmp.Or(opts.Type, "ollama") {
+	case "ollama":
+		return newOllama(opts)
+	case "openai":
+		return newOpenAI(opts)
+	case "mock":
+		return newMock(opts, nil)
+	}
+	return nil, fmt.Errorf("unknown backend type: %s", opts.Type)
+}
+
+// connect creates a backend for every persona that needs one, sharing them
+// between personas configured alike.
+func (c *Dialogue) connect() error {
+	c.backends = make(map[BotID]Backend)
+	shared := make(map[options.BackendOptions]Backend)
+
+	for _, botID := range c.speakers() {
+		p := c.speaker(botID)
+		if p.Human {
+			continue
+		}
+
+		if p.Backend.Type == "mock" {
+			// Mocks follow a per-persona script, so are never shared
+			backend, err := c.newMockFor(botID)
+			if err != nil {
+				return fmt.Errorf("error connecting backend for %s: %w", p.Name, err)
+			}
+			c.backends[botID] = backend
+			continue
+		}
+
+		backend, ok := shared[p.Backend]
+		if !ok {
+			var err error
+			if backend, err = NewBackend(p.Backend); err != nil {
+				return fmt.Errorf("error connecting backend for %s: %w", p.Name, err)
+			}
+			shared[p.Backend] = backend
+		}
+
+		c.backends[botID] = backend
+	}
+
+	return nil
+}
+
+// speakers lists every persona that may speak, including any moderator.
+func (c *Dialogue) speakers() []BotID {
+	speakers := make([]BotID, 0, len(c.Personas)+1)
+	for i := range c.Personas {
+		speakers = append(speakers, BotID(i))
+	}
+	if c.Moderator != nil {
+		speakers = append(speakers, Moderator)
+	}
+	return speakers
+}
+
+// backend returns the backend serving botID.
+func (c *Dialogue) backend(botID BotID) Backend {
+	return c.backends[botID]
+}
+
+// helperKey marks the context of an incidental request made on behalf of a
+// helper persona.
+type helperKey struct{}
+
+// helperContext marks ctx as carrying an incidental request, so backends
+// such as Mock can tell it apart from a turn of the dialogue.
+func helperContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, helperKey{}, true)
+}
+
+// isHelperRequest reports whether ctx carries an incidental request.
+func isHelperRequest(ctx context.Context) bool {
+	helper, _ := ctx.Value(helperKey{}).(bool)
+	return helper
+}
+
+// helper picks a model-backed persona to run incidental requests such as
+// choosing the next speaker, preferring botID if it isn't played by a human.
+func (c *Dialogue) helper(botID BotID) (BotID, error) {
+	if !c.speaker(botID).Human {
+		return botID, nil
+	}
+	if c.Moderator != nil {
+		return Moderator, nil
+	}
+	for i, p := range c.Personas {
+		if !p.Human {
+			return BotID(i), nil
+		}
+	}
+	return 0, fmt.Errorf("every persona is played by a human")
+}
+
+// newOllama returns an Ollama client, configured from OLLAMA_HOST unless a URL is given.
+func newOllama(opts options.BackendOptions) (Backend, error) {
+	if opts.URL == "" && opts.APIKeyEnv == "" {
+		return api.ClientFromEnvironment()
+	}
+
+	base, err := url.Parse(cmp.Or(opts.URL, "http://127.0.0.1:11434"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ollama backend url: %w", err)
+	}
+
+	httpClient := http.DefaultClient
+	if opts.APIKeyEnv != "" {
+		// Ollama doesn't authenticate, but a reverse proxy in front of it might
+		httpClient = &http.Client{Transport: bearerAuth{key: os.Getenv(opts.APIKeyEnv)}}
+	}
+
+	return api.NewClient(base, httpClient), nil
+}
+
+// bearerAuth adds an Authorization header to every request.
+type bearerAuth struct {
+	key string
+}
+
+func (b bearerAuth) RoundTrip(req *http.Request) (*http.Response, error) {
+	if b.key != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+b.key)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,157 @@
+package dialogue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/cassette"
+)
+
+// Record wraps every backend so that each request and response is captured
+// to a cassette at filePath.
+func (c *Dialogue) Record(filePath string) {
+	tape := cassette.New(filePath)
+	for botID, backend := range c.backends {
+		c.backends[botID] = &recorder{backend: backend, tape: tape, persona: c.speaker(botID).Name}
+	}
+}
+
+// Replay replaces every backend with responses served from the cassette at
+// filePath, failing if the dialogue asks for anything that wasn't recorded.
+func (c *Dialogue) Replay(filePath string) error {
+	tape, err := cassette.Load(filePath)
+	if err != nil {
+		return err
+	}
+
+	for _, botID := range c.speakers() {
+		if !c.speaker(botID).Human {
+			c.backends[botID] = &replayer{tape: tape, persona: c.speaker(botID).Name}
+		}
+	}
+
+	return nil
+}
+
+// recorder passes requests through to a live backend, capturing each
+// interaction on the tape.
+type recorder struct {
+	backend Backend
+	tape    *cassette.Cassette
+	persona string
+
+	mu   sync.Mutex
+	turn int
+}
+
+func (r *recorder) Chat(ctx context.Context, req *api.ChatRequest, fn api.ChatResponseFunc) error {
+	var (
+		content strings.Builder
+		final   api.ChatResponse
+	)
+
+	if err := r.backend.Chat(ctx, req, func(cr api.ChatResponse) error {
+		content.WriteString(cr.Message.Content)
+		if cr.Done {
+			final = cr
+		}
+		return fn(cr)
+	}); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	turn := r.turn
+	r.turn++
+	r.mu.Unlock()
+
+	return r.tape.Record(cassette.Interaction{
+		Persona: r.persona,
+		Turn:    turn,
+		Request: cassetteRequest(req),
+		Response: cassette.Response{
+			Model:           final.Model,
+			Content:         content.String(),
+			DoneReason:      final.DoneReason,
+			PromptEvalCount: final.PromptEvalCount,
+			EvalCount:       final.EvalCount,
+			TotalDuration:   final.TotalDuration,
+		},
+	})
+}
+
+// replayer serves recorded responses in place of a live backend.
+type replayer struct {
+	tape    *cassette.Cassette
+	persona string
+
+	mu   sync.Mutex
+	turn int
+}
+
+func (r *replayer) Chat(ctx context.Context, req *api.ChatRequest, fn api.ChatResponseFunc) error {
+	r.mu.Lock()
+	turn := r.turn
+	r.turn++
+	r.mu.Unlock()
+
+	interaction, ok := r.tape.Find(r.persona, turn)
+	if !ok {
+		return fmt.Errorf("replay: no recording for %s turn %d", r.persona, turn)
+	}
+
+	if err := drift(interaction.Request, cassetteRequest(req)); err != nil {
+		return fmt.Errorf("replay: prompt drift for %s turn %d: %w", r.persona, turn, err)
+	}
+
+	response := api.ChatResponse{
+		Model:      interaction.Response.Model,
+		Message:    api.Message{Role: assistantRole, Content: interaction.Response.Content},
+		Done:       true,
+		DoneReason: interaction.Response.DoneReason,
+	}
+	response.PromptEvalCount = interaction.Response.PromptEvalCount
+	response.EvalCount = interaction.Response.EvalCount
+	response.TotalDuration = interaction.Response.TotalDuration
+
+	return fn(response)
+}
+
+func cassetteRequest(req *api.ChatRequest) cassette.Request {
+	r := cassette.Request{
+		Model:    req.Model,
+		Messages: make([]cassette.Message, 0, len(req.Messages)),
+		Options:  req.Options,
+	}
+	for _, m := range req.Messages {
+		r.Messages = append(r.Messages, cassette.Message{Role: m.Role, Content: m.Content})
+	}
+	return r
+}
+
+// drift describes the first difference between the recorded and actual
+// prompts, or returns nil if the model and messages are identical.
+func drift(recorded, actual cassette.Request) error {
+	if recorded.Model != actual.Model {
+		return fmt.Errorf("model %q, recorded %q", actual.Model, recorded.Model)
+	}
+
+	for i := range max(len(recorded.Messages), len(actual.Messages)) {
+		switch {
+		case i >= len(recorded.Messages):
+			return fmt.Errorf("unexpected message %d (%s): %q", i, actual.Messages[i].Role, actual.Messages[i].Content)
+		case i >= len(actual.Messages):
+			return fmt.Errorf("missing message %d (%s): %q", i, recorded.Messages[i].Role, recorded.Messages[i].Content)
+		case recorded.Messages[i] != actual.Messages[i]:
+			return fmt.Errorf("message %d is %s %q, recorded %s %q", i,
+				actual.Messages[i].Role, actual.Messages[i].Content,
+				recorded.Messages[i].Role, recorded.Messages[i].Content)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package dialogue
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/cassette"
+)
+
+func TestDrift(t *testing.T) {
+	recorded := cassette.Request{
+		Model: "gemma3",
+		Messages: []cassette.Message{
+			{Role: systemRole, Content: "Be brief."},
+			{Role: userRole, Content: "Hello"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *cassette.Request)
+		want   string
+	}{
+		{"identical", func(r *cassette.Request) {}, ""},
+		{"model", func(r *cassette.Request) { r.Model = "llama3" }, `model "llama3", recorded "gemma3"`},
+		{"changed", func(r *cassette.Request) { r.Messages[0].Content = "Be long." }, `message 0 is system "Be long.", recorded system "Be brief."`},
+		{"extra", func(r *cassette.Request) {
+			r.Messages = append(r.Messages, cassette.Message{Role: assistantRole, Content: "Hi"})
+		}, `unexpected message 2 (assistant): "Hi"`},
+		{"missing", func(r *cassette.Request) { r.Messages = r.Messages[:1] }, `missing message 1 (user): "Hello"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := recorded
+			actual.Messages = append([]cassette.Message(nil), recorded.Messages...)
+			tt.modify(&actual)
+
+			err := drift(recorded, actual)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("drift() = %v, want nil", err)
+			case tt.want != "" && (err == nil || err.Error() != tt.want):
+				t.Errorf("drift() = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// chatRequest builds a non-streaming request with a single user message.
+func chatRequest(content string) *api.ChatRequest {
+	return &api.ChatRequest{
+		Model:    "mock",
+		Messages: []api.Message{{Role: userRole, Content: content}},
+		Stream:   new(bool),
+	}
+}
+
+// reply sends req to backend, returning the content of its response.
+func reply(t *testing.T, backend Backend, req *api.ChatRequest) (string, error) {
+	t.Helper()
+	var content strings.Builder
+	err := backend.Chat(t.Context(), req, func(cr api.ChatResponse) error {
+		content.WriteString(cr.Message.Content)
+		return nil
+	})
+	return content.String(), err
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	rec := &recorder{backend: &Mock{Lines: []string{"first", "second"}}, tape: cassette.New(path), persona: "Ann"}
+	for _, prompt := range []string{"one", "two"} {
+		if _, err := reply(t, rec, chatRequest(prompt)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tape, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := &replayer{tape: tape, persona: "Ann"}
+
+	for i, want := range []string{"first", "second"} {
+		got, err := reply(t, replay, chatRequest([]string{"one", "two"}[i]))
+		if err != nil {
+			t.Fatalf("turn %d: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("turn %d = %q, want %q", i, got, want)
+		}
+	}
+
+	if _, err := reply(t, replay, chatRequest("three")); err == nil || !strings.Contains(err.Error(), "no recording for Ann turn 2") {
+		t.Errorf("replay past the recording = %v, want no recording", err)
+	}
+}
+
+func TestReplayFailsOnDrift(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	rec := &recorder{backend: &Mock{Lines: []string{"first"}}, tape: cassette.New(path), persona: "Ann"}
+	if _, err := reply(t, rec, chatRequest("one")); err != nil {
+		t.Fatal(err)
+	}
+
+	tape, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = reply(t, &replayer{tape: tape, persona: "Ann"}, chatRequest("edited"))
+	if err == nil || !strings.Contains(err.Error(), "prompt drift for Ann turn 0") {
+		t.Errorf("replay with an edited prompt = %v, want prompt drift", err)
+	}
+}
@@ -0,0 +1,126 @@
+package dialogue
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+const (
+	// defaultContextWindow is the number of recent messages kept verbatim
+	// when a windowed strategy doesn't say
+	defaultContextWindow = 20
+
+	// Asks for older turns to be condensed
+	summarisePrompt = `Summarise the conversation that follows for the participants to refer back to.
+		 Keep who said what, decisions made, facts established and unresolved threads. Be concise and neutral, and write in the third person.`
+
+	// Carries the summary of older turns into each request
+	summaryPrompt = `Summary of the conversation so far, before the messages that follow:
+%s`
+)
+
+// estimateTokens roughly counts the tokens in text, at about four characters
+// per token.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// contextWindow returns the number of recent messages kept verbatim.
+func (c *Dialogue) contextWindow() int {
+	return cmp.Or(c.Context.Window, defaultContextWindow)
+}
+
+// history returns the messages to send verbatim under the context strategy.
+func (c *Dialogue) history() []*Message {
+	switch c.Context.Strategy {
+	case "sliding-window":
+		return c.Messages[max(0, len(c.Messages)-c.contextWindow()):]
+	case "summarise":
+		return c.Messages[c.summarised:]
+	}
+	return c.Messages
+}
+
+// fit drops the oldest history until the estimated size of the request fits
+// within botID's context window, so that the system prompts are never
+// truncated. A quarter of the window is left free for the response.
+func (c *Dialogue) fit(botID BotID, system, history []api.Message) []api.Message {
+	numCtx := c.speaker(botID).Options.NumCtx
+	if numCtx <= 0 {
+		return history
+	}
+	budget := numCtx - numCtx/4
+
+	used := 0
+	for _, m := range system {
+		used += estimateTokens(m.Content)
+	}
+	if used > budget {
+		slog.Warn("system prompts alone exceed the context window", slog.String("persona", c.speaker(botID).Name), slog.Int("tokens", used), slog.Int("num_ctx", numCtx))
+	}
+
+	keep := len(history)
+	for keep > 0 {
+		tokens := estimateTokens(history[keep-1].Content)
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		keep--
+	}
+
+	slog.Debug("estimated request size", slog.String("persona", c.speaker(botID).Name), slog.Int("tokens", used), slog.Int("num_ctx", numCtx), slog.Int("dropped", keep))
+
+	return history[keep:]
+}
+
+// summarise condenses older messages into the running summary once a
+// window's worth of them has built up beyond the window kept verbatim.
+func (c *Dialogue) summarise(ctx context.Context, botID BotID) error {
+	if c.Context.Strategy != "summarise" {
+		return nil
+	}
+
+	end := len(c.Messages) - c.contextWindow()
+	if end-c.summarised < c.contextWindow() {
+		return nil
+	}
+
+	helper, err := c.helper(botID)
+	if err != nil {
+		return err
+	}
+
+	var script strings.Builder
+	if c.summary != "" {
+		fmt.Fprintf(&script, "Summary of earlier conversation: %s\n\n", c.summary)
+	}
+	script.WriteString(c.script(c.Messages[c.summarised:end]))
+
+	chatRequest := api.ChatRequest{
+		Model: cmp.Or(c.Context.Model, c.speaker(helper).Model),
+		Messages: append(
+			systemMessages(c.Scenario.Scenario, summarisePrompt),
+			newMessage(userRole, script.String()),
+		),
+		Stream: new(bool),
+	}
+
+	var summary string
+	if err := c.backend(helper).Chat(helperContext(ctx), &chatRequest, func(cr api.ChatResponse) error {
+		summary += cr.Message.Content
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	slog.Debug("summarised older messages", slog.Int("messages", end-c.summarised), slog.Int("tokens", estimateTokens(summary)))
+
+	c.summary, c.summarised = strings.TrimSpace(summary), end
+	return nil
+}
@@ -1,13 +1,20 @@
 package dialogue
 
 import (
+	"cmp"
 	"context"
-	"log/slog"
+	"errors"
+	"fmt"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ollama/ollama/api"
 
 	"github.com/isometry/yaketty/internal/config"
+	"github.com/isometry/yaketty/internal/limits"
+	"github.com/isometry/yaketty/internal/options"
 	"github.com/isometry/yaketty/internal/output"
 	"github.com/isometry/yaketty/internal/persona"
 	"github.com/isometry/yaketty/internal/scenario"
@@ -20,6 +27,9 @@ const (
 	Persona2
 )
 
+// Moderator identifies the scenario moderator, who is not seated with the participants.
+const Moderator BotID = -1
+
 // Configuration constants
 const (
 	reminderInterval    = 12
@@ -42,13 +52,30 @@ var (
 		 Your character details and scenario context follow.`,
 	}
 
+	// Explains the conversation format when more than two personas take part
+	groupPrompt = `This is a group conversation between %d characters: %s. You are %s.
+		 Each message from another character is prefixed with their name. Do not prefix your own responses.`
+
+	// Tells participants how to recognise the moderator
+	moderatorNotice = `%s moderates this scenario and will occasionally interject with narration or direction.
+		 Their messages are prefixed with "%s (moderator):". Take their lead, but never speak for them.`
+
+	// Instructions for the moderator themselves
+	moderatorPrompt = `You are the moderator of a dialogue scenario between %s, not a participant in it.
+		 Interject briefly to steer the scene: introduce new topics, describe scene changes, resolve actions or report dice results.
+		 Each participant's message is prefixed with their name. Do not prefix your own responses, and never speak for the participants.
+		 Your character details and scenario context follow.`
+
 	// Brief periodic reminders (injected every ~10-15 exchanges)
 	periodicReminder = `Remember: stay true to your character and the scenario context.`
-)
 
-func (b BotID) Opponent() BotID {
-	return 1 - b
-}
+	// errDurationLimit is the cancellation cause when limits.MaxDuration expires
+	errDurationLimit = errors.New("maximum duration reached")
+
+	// ErrInterrupted is returned by Start when the dialogue's context is
+	// cancelled, such as by Ctrl-C, after wrapping up the dialogue
+	ErrInterrupted = errors.New("dialogue interrupted")
+)
 
 type Dialogue struct {
 	// Embedded scenario configuration
@@ -56,15 +83,45 @@ type Dialogue struct {
 
 	// Configuration
 	ExtraPrompts []string
-	Personas     [2]*persona.Persona
+	Personas     []*persona.Persona
+	Order        TurnOrder
 	Output       output.OutputStyle
+	Stream       bool
+	Status       bool
+	SavePath     string
+	Context      options.ContextOptions
+
+	// Title names the dialogue in outputs with a title page
+	Title string
+
+	// Input supplies lines typed by the user for human personas
+	Input <-chan string
 
 	// Runtime state
 	Messages []*Message
+	Usage    limits.Usage
+	started  time.Time
+
+	// Summary of the first summarised messages, under the summarise strategy
+	summary    string
+	summarised int
+
+	// Turn scheduling
+	mu             sync.Mutex
+	next           BotID
+	afterModerator BotID
+	state          State
+	resumed        chan struct{}
+	cancelTurn     context.CancelFunc
+	skipped        bool
+	notes          map[BotID][]string
+	// awaiting is set while a human persona is being asked for a line
+	awaiting bool
 
 	// Internal dependencies
-	ctx    context.Context
-	client *api.Client
+	ctx      context.Context
+	backends map[BotID]Backend
+	config   *config.Config
 }
 
 const (
@@ -76,6 +133,11 @@ const (
 type Message struct {
 	persona BotID
 	content string
+	model   string
+	time    time.Time
+	metrics api.Metrics
+	// latency is the wall-clock time from request to complete response
+	latency time.Duration
 }
 
 func newMessage(role, content string) api.Message {
@@ -92,112 +154,225 @@ func systemMessages(contents ...string) []api.Message {
 }
 
 func NewDialogue(ctx context.Context, cfg *config.Config) (*Dialogue, error) {
-	client, err := api.ClientFromEnvironment()
+	order, err := NewTurnOrder(cfg.TurnOrder)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Dialogue{
+	personas := make([]*persona.Persona, len(cfg.Personas))
+	for i := range cfg.Personas {
+		personas[i] = &cfg.Personas[i]
+	}
+
+	c := &Dialogue{
 		ctx:          ctx,
-		client:       client,
+		config:       cfg,
 		Scenario:     cfg.Scenario,
 		ExtraPrompts: cfg.ExtraPrompts,
-		Personas: [2]*persona.Persona{
-			&cfg.Persona1,
-			&cfg.Persona2,
+		Personas:     personas,
+		Order:        order,
+		Output:       output.Text{W: os.Stdout},
+		Stream:       cfg.Stream,
+		Context:      cfg.Context,
+	}
+
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// AddMessage renders m and records it in the dialogue.
+func (c *Dialogue) AddMessage(m *Message) {
+	c.Output.Render(c.outputMessage(m))
+	c.appendMessage(m)
+}
+
+// outputMessage describes m for rendering.
+func (c *Dialogue) outputMessage(m *Message) output.Message {
+	om := output.Message{
+		Name:      c.speaker(m.persona).Name,
+		Content:   m.content,
+		Moderator: m.persona == Moderator,
+		Turn:      len(c.Messages),
+		Persona:   int(m.persona),
+		Time:      m.time,
+		Metrics: output.Metrics{
+			PromptTokens: m.metrics.PromptEvalCount,
+			Tokens:       m.metrics.EvalCount,
+			EvalDuration: m.metrics.EvalDuration,
+			Latency:      m.latency,
 		},
-		Output: output.Text{},
-	}, nil
+		WordsPerMinute: c.speaker(m.persona).WordsPerMinute,
+	}
+	if !c.speaker(m.persona).Human {
+		om.Model = m.model
+	}
+	return om
 }
 
-func (c *Dialogue) AddMessage(botID BotID, content string) {
-	c.Output.Render(c.Personas[botID].Name, content)
-	c.Messages = append(c.Messages, &Message{botID, content})
+// appendMessage records m without rendering it.
+func (c *Dialogue) appendMessage(m *Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Messages = append(c.Messages, m)
 }
 
-func (c *Dialogue) FromPerspective(botID BotID) api.ChatRequest {
-	prompts := make([]string, 0, 6+len(defaultPrompts)+len(c.ExtraPrompts))
+// title describes the dialogue for outputs with a title page, named after
+// its cast unless given a Title.
+func (c *Dialogue) title() output.Title {
+	t := output.Title{
+		Title:    cmp.Or(c.Title, strings.Join(c.names(), " and ")),
+		Scenario: c.Scenario.Scenario,
+	}
+	for _, botID := range c.speakers() {
+		t.Cast = append(t.Cast, output.CastMember{
+			Name:      c.speaker(botID).Name,
+			Persona:   c.speaker(botID).Persona,
+			Moderator: botID == Moderator,
+		})
+	}
+	return t
+}
+
+// speaker returns the persona behind botID, including the moderator.
+func (c *Dialogue) speaker(botID BotID) *persona.Persona {
+	if botID == Moderator {
+		return &c.Moderator.Persona
+	}
+	return c.Personas[botID]
+}
+
+// names lists the seated participants' names.
+func (c *Dialogue) names() []string {
+	names := make([]string, len(c.Personas))
+	for i, p := range c.Personas {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// following returns the persona seated after botID.
+func (c *Dialogue) following(botID BotID) BotID {
+	return (botID + 1) % BotID(len(c.Personas))
+}
+
+// role returns the scenario role for botID, if the scenario defines one.
+func (c *Dialogue) role(botID BotID) string {
+	if int(botID) < len(c.Roles) {
+		return c.Roles[botID]
+	}
+	return ""
+}
+
+// isGroup reports whether more than two personas are taking part.
+func (c *Dialogue) isGroup() bool {
+	return len(c.Personas) > 2
+}
+
+// basePrompts returns the system prompts common to every request from botID's perspective.
+func (c *Dialogue) basePrompts(botID BotID) []string {
+	if botID == Moderator {
+		return append([]string{
+			fmt.Sprintf(moderatorPrompt, strings.Join(c.names(), ", ")),
+			c.Scenario.Scenario,
+			c.Moderator.Persona.Persona,
+		}, c.Moderator.Prompts...)
+	}
+
+	prompts := make([]string, 0, 8+len(defaultPrompts)+len(c.ExtraPrompts))
 	prompts = append(prompts, defaultPrompts...)
 
+	if c.isGroup() {
+		names := c.names()
+		prompts = append(prompts, fmt.Sprintf(groupPrompt, len(names), strings.Join(names, ", "), c.Personas[botID].Name))
+	}
+
+	if c.Moderator != nil {
+		prompts = append(prompts, fmt.Sprintf(moderatorNotice, c.Moderator.Name, c.Moderator.Name))
+	}
+
 	prompts = append(prompts,
 		c.Scenario.Scenario,
 		c.Personas[botID].Persona,
-		c.Roles[botID],
+		c.role(botID),
 	)
 
+	if secret := c.secret(botID); secret != "" {
+		prompts = append(prompts, fmt.Sprintf(secretPrompt, secret))
+	}
+
+	// the persona's own prompts are private to it
+	return append(prompts, c.Personas[botID].Prompts...)
+}
+
+// SystemPrompts assembles the system prompts botID receives on the given
+// turn. Persona1's prompts on turn 0 ask for the opening statement.
+func (c *Dialogue) SystemPrompts(botID BotID, turn int) []string {
+	prompts := c.basePrompts(botID)
+
+	if turn == 0 && botID == Persona1 {
+		prompts = append(prompts, c.ExtraPrompts...)
+		prompts = append(prompts, c.notesFor(botID)...)
+		return append(prompts, c.OpeningPrompt)
+	}
+
 	// Add periodic reminder every reminderInterval messages
-	if len(c.Messages) > 0 && len(c.Messages)%reminderInterval == 0 {
+	if turn > 0 && turn%reminderInterval == 0 {
 		prompts = append(prompts, periodicReminder)
 	}
 
-	prompts = append(prompts, c.ExtraPrompts...)
+	if botID != Moderator {
+		prompts = append(prompts, c.ExtraPrompts...)
+	}
+
+	return append(prompts, c.notesFor(botID)...)
+}
+
+func (c *Dialogue) FromPerspective(botID BotID) api.ChatRequest {
+	prompts := c.SystemPrompts(botID, len(c.Messages))
+	if c.summary != "" {
+		prompts = append(prompts, fmt.Sprintf(summaryPrompt, c.summary))
+	}
 
-	messages := make([]api.Message, 0, len(c.Messages)+len(prompts)+1)
-	messages = append(messages, systemMessages(prompts...)...)
+	history := c.history()
+	messages := make([]api.Message, 0, len(history))
 
-	for _, m := range c.Messages {
-		if m.persona == botID {
+	for _, m := range history {
+		switch {
+		case m.persona == botID:
 			messages = append(messages, newMessage(assistantRole, m.content))
-		} else {
+		case m.persona == Moderator:
+			messages = append(messages, newMessage(userRole, c.Moderator.Name+" (moderator): "+m.content))
+		case c.isGroup() || botID == Moderator:
+			messages = append(messages, newMessage(userRole, c.Personas[m.persona].Name+": "+m.content))
+		default:
 			messages = append(messages, newMessage(userRole, m.content))
 		}
 	}
 
+	system := systemMessages(prompts...)
+
 	cr := api.ChatRequest{
-		Model:    c.Personas[botID].Model,
-		Messages: messages,
-		Options:  c.Personas[botID].Options.AsMap(),
-		Stream:   func() *bool { b := false; return &b }(),
+		Model:    c.speaker(botID).Model,
+		Messages: append(system, c.fit(botID, system, messages)...),
+		Options:  c.speaker(botID).Options.AsMap(),
+		Stream:   &c.Stream,
 	}
 
 	return cr
 }
 
-func (c *Dialogue) SendRequest(botID BotID) error {
-	chatRequest := c.FromPerspective(botID)
-	slog.Debug("sending chat request", slog.String("perspective", c.Personas[botID].Name), slog.Any("chatRequest", chatRequest))
-	return c.client.Chat(c.ctx, &chatRequest, c.HandleResponse(botID))
-}
-
-func (c *Dialogue) HandleResponse(botID BotID) func(api.ChatResponse) error {
-	return func(cr api.ChatResponse) error {
-		message := strings.TrimSpace(cr.Message.Content)
-		if len(message) == 0 && len(c.Messages) > 0 {
-			if len(c.Messages[len(c.Messages)-1].content) == 0 {
-				return nil
-			} else {
-				c.AddMessage(botID, "...")
-			}
-		} else {
-			c.AddMessage(botID, message)
-		}
-		return c.SendRequest(botID.Opponent())
-	}
-}
-
-func (c *Dialogue) Start() error {
+// OpeningRequest builds the request that asks Persona1 to open the dialogue.
+func (c *Dialogue) OpeningRequest() api.ChatRequest {
 	// Send opening prompt to Persona1 as a system instruction
-	prompts := make([]string, 0, 6+len(defaultPrompts)+len(c.ExtraPrompts)+1)
-	prompts = append(prompts, defaultPrompts...)
+	messages := systemMessages(c.SystemPrompts(Persona1, 0)...)
 
-	prompts = append(prompts,
-		c.Scenario.Scenario,
-		c.Personas[Persona1].Persona,
-		c.Roles[Persona1],
-	)
-
-	prompts = append(prompts, c.ExtraPrompts...)
-	prompts = append(prompts, c.OpeningPrompt)
-
-	messages := systemMessages(prompts...)
-
-	chatRequest := api.ChatRequest{
+	return api.ChatRequest{
 		Model:    c.Personas[Persona1].Model,
 		Messages: messages,
 		Options:  c.Personas[Persona1].Options.AsMap(),
-		Stream:   func() *bool { b := false; return &b }(),
+		Stream:   &c.Stream,
 	}
-
-	slog.Debug("sending opening request", slog.String("perspective", c.Personas[Persona1].Name), slog.Any("chatRequest", chatRequest))
-	return c.client.Chat(c.ctx, &chatRequest, c.HandleResponse(Persona1))
 }
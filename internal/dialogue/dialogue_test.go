@@ -0,0 +1,42 @@
+package dialogue
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isometry/yaketty/internal/config"
+	"github.com/isometry/yaketty/internal/options"
+	"github.com/isometry/yaketty/internal/output"
+	"github.com/isometry/yaketty/internal/persona"
+)
+
+// newMockDialogue seats two lorem-generating mock personas, rendering to out.
+func newMockDialogue(t *testing.T, maxTurns int, out *strings.Builder) *Dialogue {
+	t.Helper()
+
+	cfg := &config.Config{
+		Personas: []persona.Persona{
+			{Name: "Ann", Model: "mock", Backend: options.BackendOptions{Type: "mock", Script: "lorem"}},
+			{Name: "Bob", Model: "mock", Backend: options.BackendOptions{Type: "mock", Script: "lorem"}},
+		},
+	}
+	cfg.Scenario.Scenario = "A test."
+	cfg.OpeningPrompt = "Begin."
+	cfg.Limits.MaxTurns = maxTurns
+
+	c, err := NewDialogue(t.Context(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Output = output.Text{W: out}
+	return c
+}
+
+// speakers lists who spoke each message of c.
+func speakers(c *Dialogue) []BotID {
+	ids := make([]BotID, len(c.Messages))
+	for i, m := range c.Messages {
+		ids[i] = m.persona
+	}
+	return ids
+}
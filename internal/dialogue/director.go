@@ -0,0 +1,148 @@
+package dialogue
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const directorHelp = `Director commands:
+  /note <persona> <instruction>  privately instruct a persona on their next turn
+  /topic <topic>                 steer every persona towards a new topic
+  /pause, /resume                pause or resume the dialogue
+  /skip                          abandon the current turn
+  /stop                          end the dialogue
+Personas may be named as persona1, 1, moderator, or by name.`
+
+// AddNote queues a transient system prompt for botID's next turn.
+func (c *Dialogue) AddNote(botID BotID, note string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.notes == nil {
+		c.notes = make(map[BotID][]string)
+	}
+	c.notes[botID] = append(c.notes[botID], note)
+}
+
+// notesFor returns the notes queued for botID's next turn.
+func (c *Dialogue) notesFor(botID BotID) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.notes[botID]
+}
+
+// clearNotes discards the first n notes once botID has acted on them,
+// keeping any queued while its turn was under way for the next one.
+func (c *Dialogue) clearNotes(botID BotID, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n >= len(c.notes[botID]) {
+		delete(c.notes, botID)
+		return
+	}
+	c.notes[botID] = slices.Clone(c.notes[botID][n:])
+}
+
+// Lookup resolves a reference to a persona: a seat such as
+// "persona2" or "2", "moderator", or a persona name or first name.
+func (c *Dialogue) Lookup(ref string) (BotID, bool) {
+	ref = strings.ToLower(ref)
+
+	if ref == "moderator" && c.Moderator != nil {
+		return Moderator, true
+	}
+
+	if seat, err := strconv.Atoi(strings.TrimPrefix(ref, "persona")); err == nil && seat >= 1 && seat <= len(c.Personas) {
+		return BotID(seat - 1), true
+	}
+
+	for i, p := range c.Personas {
+		name := strings.Fields(strings.ToLower(p.Name))
+		if strings.Join(name, " ") == ref || (len(name) > 0 && name[0] == ref) {
+			return BotID(i), true
+		}
+	}
+
+	return 0, false
+}
+
+// Direct watches lines for director commands, applying them to the dialogue
+// as they arrive. Other lines typed while a human persona is being asked are
+// passed through on the returned channel as their Input; the rest are
+// dropped, so a stray line never holds up the commands behind it.
+func (c *Dialogue) Direct(lines <-chan string) <-chan string {
+	// room for one line, should it arrive before ask is ready to receive it
+	passthrough := make(chan string, 1)
+
+	go func() {
+		defer close(passthrough)
+		for line := range lines {
+			if !strings.HasPrefix(line, "/") {
+				switch {
+				case !c.NeedsInput():
+					fmt.Fprintln(os.Stderr, "director: commands start with / (try /help)")
+				case !c.Awaiting():
+					fmt.Fprintln(os.Stderr, "director: ignoring line typed out of turn (commands start with /)")
+				default:
+					select {
+					case passthrough <- line:
+					default:
+						fmt.Fprintln(os.Stderr, "director: ignoring line typed before the last was read")
+					}
+				}
+				continue
+			}
+			if err := c.direct(line); err != nil {
+				fmt.Fprintf(os.Stderr, "director: %v\n", err)
+			}
+		}
+	}()
+
+	return passthrough
+}
+
+// direct applies a single director command.
+func (c *Dialogue) direct(line string) error {
+	command, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	args = strings.TrimSpace(args)
+
+	switch command {
+	case "/note":
+		ref, note, _ := strings.Cut(args, " ")
+		note = strings.TrimSpace(note)
+		if note == "" {
+			return fmt.Errorf("usage: /note <persona> <instruction>")
+		}
+		botID, ok := c.Lookup(ref)
+		if !ok {
+			return fmt.Errorf("unknown persona: %s", ref)
+		}
+		c.AddNote(botID, "Director's note, for your next response only: "+note)
+	case "/topic":
+		if args == "" {
+			return fmt.Errorf("usage: /topic <topic>")
+		}
+		for i := range c.Personas {
+			c.AddNote(BotID(i), "Director's note: steer the conversation towards a new topic: "+args)
+		}
+		if c.Moderator != nil {
+			c.AddNote(Moderator, "Director's note: introduce a new topic: "+args)
+		}
+	case "/pause":
+		c.Pause()
+	case "/resume":
+		c.Resume()
+	case "/skip":
+		c.Skip()
+	case "/stop":
+		c.Stop()
+	case "/help":
+		fmt.Fprintln(os.Stderr, directorHelp)
+	default:
+		return fmt.Errorf("unknown command %s (try /help)", command)
+	}
+
+	return nil
+}
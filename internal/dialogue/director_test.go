@@ -0,0 +1,122 @@
+package dialogue
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/persona"
+)
+
+// send delivers line to the director, failing if it is not read promptly.
+func send(t *testing.T, lines chan<- string, line string) {
+	t.Helper()
+	select {
+	case lines <- line:
+	case <-time.After(time.Second):
+		t.Fatalf("director blocked before reading %q", line)
+	}
+}
+
+func humanDialogue() *Dialogue {
+	return &Dialogue{
+		Personas: []*persona.Persona{{Name: "Ann", Human: true}, {Name: "Bob"}},
+		state:    Running,
+	}
+}
+
+func TestDirectPauseLineResume(t *testing.T) {
+	c := humanDialogue()
+	lines := make(chan string)
+	input := c.Direct(lines)
+
+	send(t, lines, "/pause")
+	send(t, lines, "")
+	send(t, lines, "a line typed out of turn")
+	send(t, lines, "/resume")
+	close(lines)
+
+	select {
+	case line, ok := <-input:
+		if ok {
+			t.Fatalf("line typed out of turn was passed through: %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("director did not finish")
+	}
+
+	if state := c.State(); state != Running {
+		t.Errorf("state = %s, want running", state)
+	}
+}
+
+func TestDirectPassesLinesWhileAwaiting(t *testing.T) {
+	c := humanDialogue()
+	lines := make(chan string)
+	input := c.Direct(lines)
+
+	c.setAwaiting(true)
+	send(t, lines, "hello")
+	send(t, lines, "/pause")
+
+	select {
+	case line := <-input:
+		if line != "hello" {
+			t.Errorf("input = %q, want %q", line, "hello")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("line was not passed through")
+	}
+
+	close(lines)
+	if _, ok := <-input; ok {
+		t.Error("input not closed with lines")
+	}
+	if state := c.State(); state != Paused {
+		t.Errorf("state = %s, want paused", state)
+	}
+}
+
+// hook runs before each request is passed on to backend.
+type hook struct {
+	backend Backend
+	before  func(req *api.ChatRequest)
+}
+
+func (h hook) Chat(ctx context.Context, req *api.ChatRequest, fn api.ChatResponseFunc) error {
+	h.before(req)
+	return h.backend.Chat(ctx, req, fn)
+}
+
+func TestNoteDuringOwnTurnIsKept(t *testing.T) {
+	var out strings.Builder
+	c := newMockDialogue(t, 0, &out)
+	c.state = Running
+
+	c.AddNote(Persona1, "early note")
+	var sent bool
+	c.backends[Persona1] = hook{backend: c.backends[Persona1], before: func(req *api.ChatRequest) {
+		sent = slices.ContainsFunc(req.Messages, func(m api.Message) bool { return m.Content == "early note" })
+		c.AddNote(Persona1, "late note")
+	}}
+
+	if _, err := c.Step(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !sent {
+		t.Error("early note was not sent with the turn")
+	}
+	if got, want := c.notesFor(Persona1), []string{"late note"}; !slices.Equal(got, want) {
+		t.Errorf("notes after turn = %q, want %q", got, want)
+	}
+
+	prompts := c.SystemPrompts(Persona1, 2)
+	if !slices.Contains(prompts, "late note") || slices.Contains(prompts, "early note") {
+		t.Errorf("next turn's prompts carry the wrong notes: %q", prompts)
+	}
+}
@@ -0,0 +1,90 @@
+package dialogue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// errInputClosed signals that the user has no more lines to give.
+var errInputClosed = errors.New("input closed")
+
+// ask reads the next line for a human persona from Input, reminding the user
+// of their role, secret, private prompts and the opening prompt before their first line.
+func (c *Dialogue) ask(ctx context.Context, botID BotID) (string, error) {
+	if c.Input == nil {
+		return "", fmt.Errorf("%s is played by a human but no input is available", c.speaker(botID).Name)
+	}
+
+	if !c.hasSpoken(botID) {
+		if role := c.role(botID); role != "" {
+			fmt.Fprintf(os.Stderr, "(You are playing %s: %s)\n", c.speaker(botID).Name, role)
+		}
+		if secret := c.secret(botID); secret != "" {
+			fmt.Fprintf(os.Stderr, "(Your secret objective: %s)\n", secret)
+		}
+		for _, prompt := range c.speaker(botID).Prompts {
+			fmt.Fprintf(os.Stderr, "(%s)\n", strings.TrimSpace(prompt))
+		}
+		if len(c.Messages) == 0 {
+			fmt.Fprintf(os.Stderr, "(%s)\n", strings.TrimSpace(c.OpeningPrompt))
+		}
+	}
+
+	for _, note := range c.notesFor(botID) {
+		fmt.Fprintf(os.Stderr, "(%s)\n", note)
+	}
+
+	c.setAwaiting(true)
+	defer c.setAwaiting(false)
+
+	fmt.Fprintf(os.Stderr, "%s> ", c.speaker(botID).Name)
+
+	select {
+	case line, ok := <-c.Input:
+		if !ok {
+			fmt.Fprintln(os.Stderr)
+			return "", errInputClosed
+		}
+		return line, nil
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr)
+		return "", ctx.Err()
+	}
+}
+
+// NeedsInput reports whether any persona is played by a human.
+func (c *Dialogue) NeedsInput() bool {
+	for _, p := range c.Personas {
+		if p.Human {
+			return true
+		}
+	}
+	return false
+}
+
+// setAwaiting records whether a human persona is waiting to give a line.
+func (c *Dialogue) setAwaiting(awaiting bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.awaiting = awaiting
+}
+
+// Awaiting reports whether a human persona is waiting to give a line.
+func (c *Dialogue) Awaiting() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.awaiting
+}
+
+// hasSpoken reports whether botID has contributed to the dialogue yet.
+func (c *Dialogue) hasSpoken(botID BotID) bool {
+	for _, m := range c.Messages {
+		if m.persona == botID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package dialogue
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+)
+
+// stats totals the metrics of one or more turns.
+type stats struct {
+	turns        int
+	promptTokens int
+	tokens       int
+	evalDuration time.Duration
+	latency      time.Duration
+}
+
+func (s *stats) add(m *Message) {
+	s.turns++
+	s.promptTokens += m.metrics.PromptEvalCount
+	s.tokens += m.metrics.EvalCount
+	s.evalDuration += m.metrics.EvalDuration
+	s.latency += m.latency
+}
+
+// tokensPerSecond is the generation rate over the model's own evaluation
+// time, or zero where the backend doesn't report it.
+func (s stats) tokensPerSecond() float64 {
+	if s.evalDuration <= 0 {
+		return 0
+	}
+	return float64(s.tokens) / s.evalDuration.Seconds()
+}
+
+func (s stats) String() string {
+	rate := ""
+	if tps := s.tokensPerSecond(); tps > 0 {
+		rate = fmt.Sprintf(", %.1f tok/s", tps)
+	}
+	return fmt.Sprintf("%d tokens%s, prompt %d tokens, %s", s.tokens, rate, s.promptTokens, s.latency.Round(time.Millisecond))
+}
+
+// status shows the metrics of the turn just taken on stderr, if asked for.
+// It is a live display, so is kept out of the dialogue's output.
+func (c *Dialogue) status(m *Message) {
+	if !c.Status || c.speaker(m.persona).Human {
+		return
+	}
+	var s stats
+	s.add(m)
+	fmt.Fprintf(os.Stderr, "(%s)\n", s)
+}
+
+// runSummary totals the model turns of the dialogue per persona and per model.
+func (c *Dialogue) runSummary() {
+	var (
+		personas = make(map[BotID]*stats)
+		models   = make(map[string]*stats)
+	)
+
+	for _, m := range c.Messages {
+		if c.speaker(m.persona).Human {
+			continue
+		}
+		if personas[m.persona] == nil {
+			personas[m.persona] = &stats{}
+		}
+		if models[m.model] == nil {
+			models[m.model] = &stats{}
+		}
+		personas[m.persona].add(m)
+		models[m.model].add(m)
+	}
+
+	for _, botID := range c.speakers() {
+		if s := personas[botID]; s != nil {
+			c.Output.Summary(fmt.Sprintf("  %s (%s): %d turns, %s", c.speaker(botID).Name, c.speaker(botID).Model, s.turns, s))
+		}
+	}
+
+	if len(models) < 2 {
+		// the per-persona totals already cover the only model
+		return
+	}
+
+	for _, model := range slices.Sorted(maps.Keys(models)) {
+		s := models[model]
+		c.Output.Summary(fmt.Sprintf("  %s: %d turns, %s", model, s.turns, s))
+	}
+}
@@ -0,0 +1,153 @@
+package dialogue
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ollama/ollama/api"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/isometry/yaketty/internal/options"
+)
+
+// errEndOfScript signals that a mock persona has run out of canned lines.
+var errEndOfScript = errors.New("end of script")
+
+var loremSentences = []string{
+	"Lorem ipsum dolor sit amet, consectetur adipiscing elit.",
+	"Sed do eiusmod tempor incididunt ut labore et dolore magna aliqua.",
+	"Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris.",
+	"Duis aute irure dolor in reprehenderit in voluptate velit esse.",
+	"Excepteur sint occaecat cupidatat non proident, sunt in culpa qui officia.",
+}
+
+// Mock answers chat requests without a model, for checking the flow of a
+// scenario. It either follows a script of canned lines, echoes back the last
+// system prompt it was given, or generates lorem ipsum. Incidental requests,
+// such as summaries and choosing the next speaker, are always echoed.
+type Mock struct {
+	// Lines are served in order; once they run out the dialogue ends
+	Lines []string
+	// Generator is used when there are no Lines: echo or lorem
+	Generator string
+
+	mu   sync.Mutex
+	turn int
+}
+
+func newMock(opts options.BackendOptions, lines []string) (Backend, error) {
+	if lines != nil {
+		return &Mock{Lines: lines}, nil
+	}
+	generator := cmp.Or(opts.Script, "echo")
+	if generator != "echo" && generator != "lorem" {
+		return nil, fmt.Errorf("mock script must be a file, echo or lorem: %s", generator)
+	}
+	return &Mock{Generator: generator}, nil
+}
+
+// newMockFor creates a mock for botID, picking out its lines when the script
+// is a file. Script files map personas to their lines; personas may be named
+// as for director commands, e.g. persona1, 2, moderator or by name.
+func (c *Dialogue) newMockFor(botID BotID) (Backend, error) {
+	opts := c.speaker(botID).Backend
+	if opts.Script == "" || opts.Script == "echo" || opts.Script == "lorem" {
+		return newMock(opts, nil)
+	}
+
+	data, err := os.ReadFile(opts.Script)
+	if err != nil {
+		return nil, err
+	}
+
+	var script map[string][]string
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("error parsing mock script %s: %w", opts.Script, err)
+	}
+
+	lines := []string{}
+	for ref, refLines := range script {
+		if id, ok := c.Lookup(ref); ok && id == botID {
+			lines = refLines
+		}
+	}
+
+	return newMock(opts, lines)
+}
+
+func (m *Mock) Chat(ctx context.Context, req *api.ChatRequest, fn api.ChatResponseFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if isHelperRequest(ctx) {
+		// Incidental requests are echoed, leaving the script for the dialogue
+		return m.respond(req, "(echo) "+lastSystemPrompt(req), fn)
+	}
+
+	m.mu.Lock()
+	turn := m.turn
+	m.turn++
+	m.mu.Unlock()
+
+	var content string
+	switch {
+	case m.Lines != nil:
+		if turn >= len(m.Lines) {
+			return errEndOfScript
+		}
+		content = m.Lines[turn]
+	case m.Generator == "lorem":
+		content = strings.Join([]string{
+			loremSentences[turn%len(loremSentences)],
+			loremSentences[(turn+1)%len(loremSentences)],
+		}, " ")
+	default:
+		content = "(echo) " + lastSystemPrompt(req)
+	}
+
+	return m.respond(req, content, fn)
+}
+
+// lastSystemPrompt returns the last non-empty system prompt in req.
+func lastSystemPrompt(req *api.ChatRequest) string {
+	var prompt string
+	for _, msg := range req.Messages {
+		if msg.Role == systemRole && strings.TrimSpace(msg.Content) != "" {
+			prompt = strings.TrimSpace(msg.Content)
+		}
+	}
+	return prompt
+}
+
+// respond sends content to fn, word by word if req asks to stream.
+func (m *Mock) respond(req *api.ChatRequest, content string, fn api.ChatResponseFunc) error {
+	started := time.Now()
+	words := strings.SplitAfter(content, " ")
+
+	if req.Stream != nil && *req.Stream {
+		for _, word := range words {
+			if err := fn(api.ChatResponse{Model: req.Model, Message: api.Message{Role: assistantRole, Content: word}}); err != nil {
+				return err
+			}
+		}
+		content = ""
+	}
+
+	response := api.ChatResponse{
+		Model:     req.Model,
+		CreatedAt: time.Now(),
+		Message:   api.Message{Role: assistantRole, Content: content},
+		Done:      true,
+	}
+	response.EvalCount = len(words)
+	response.TotalDuration = time.Since(started)
+
+	return fn(response)
+}
@@ -0,0 +1,38 @@
+package dialogue
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+)
+
+func TestMockHelperRequestsKeepScript(t *testing.T) {
+	m := &Mock{Lines: []string{"first", "second"}}
+
+	if got, err := reply(t, m, chatRequest("one")); err != nil || got != "first" {
+		t.Fatalf("first turn = %q, %v", got, err)
+	}
+
+	helperCtx := helperContext(t.Context())
+	for range 3 {
+		var content strings.Builder
+		if err := m.Chat(helperCtx, chatRequest("summarise"), func(cr api.ChatResponse) error {
+			content.WriteString(cr.Message.Content)
+			return nil
+		}); err != nil {
+			t.Fatalf("helper request: %v", err)
+		}
+		if !strings.HasPrefix(content.String(), "(echo)") {
+			t.Errorf("helper reply = %q, want an echo", content.String())
+		}
+	}
+
+	if got, err := reply(t, m, chatRequest("two")); err != nil || got != "second" {
+		t.Fatalf("second turn = %q, %v", got, err)
+	}
+	if _, err := reply(t, m, chatRequest("three")); !errors.Is(err, errEndOfScript) {
+		t.Errorf("third turn = %v, want end of script", err)
+	}
+}
@@ -0,0 +1,35 @@
+package dialogue
+
+import "strings"
+
+// moderatorDue reports whether the moderator should interject before the next
+// participant speaks, based on the scenario's interval and keyword triggers.
+func (c *Dialogue) moderatorDue() bool {
+	if c.Moderator == nil || len(c.Messages) == 0 {
+		return false
+	}
+
+	last := strings.ToLower(c.Messages[len(c.Messages)-1].content)
+	for _, keyword := range c.Moderator.Keywords {
+		if keyword != "" && strings.Contains(last, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+
+	interval := c.Moderator.Interval
+	if interval == 0 && len(c.Moderator.Keywords) == 0 {
+		// Without explicit triggers, narrate once per round
+		interval = len(c.Personas)
+	}
+
+	if interval <= 0 {
+		return false
+	}
+
+	since := 0
+	for i := len(c.Messages) - 1; i >= 0 && c.Messages[i].persona != Moderator; i-- {
+		since++
+	}
+
+	return since >= interval
+}
@@ -0,0 +1,219 @@
+package dialogue
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/options"
+)
+
+const (
+	defaultOpenAIURL       = "https://api.openai.com/v1"
+	defaultOpenAIAPIKeyEnv = "OPENAI_API_KEY"
+)
+
+// OpenAI talks to any server implementing the OpenAI chat completions
+// protocol, such as llama.cpp server, vLLM or LM Studio.
+type OpenAI struct {
+	BaseURL string
+	APIKey  string
+	HTTP    *http.Client
+}
+
+func newOpenAI(opts options.BackendOptions) (Backend, error) {
+	return &OpenAI{
+		BaseURL: strings.TrimSuffix(cmp.Or(opts.URL, os.Getenv("OPENAI_BASE_URL"), defaultOpenAIURL), "/"),
+		APIKey:  os.Getenv(cmp.Or(opts.APIKeyEnv, defaultOpenAIAPIKeyEnv)),
+		HTTP:    http.DefaultClient,
+	}, nil
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	Stream        bool                 `json:"stream"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+	Temperature   any                  `json:"temperature,omitempty"`
+	TopP          any                  `json:"top_p,omitempty"`
+	Stop          []string             `json:"stop,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		Delta        openAIMessage `json:"delta"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// newOpenAIRequest translates an Ollama chat request, keeping the sampling
+// options that the OpenAI protocol understands.
+func newOpenAIRequest(req *api.ChatRequest) openAIRequest {
+	r := openAIRequest{
+		Model:       req.Model,
+		Messages:    make([]openAIMessage, 0, len(req.Messages)),
+		Stream:      req.Stream != nil && *req.Stream,
+		Temperature: req.Options["temperature"],
+		TopP:        req.Options["top_p"],
+	}
+
+	if stop, ok := req.Options["stop"].([]string); ok {
+		r.Stop = stop
+	}
+
+	if r.Stream {
+		r.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	for _, m := range req.Messages {
+		r.Messages = append(r.Messages, openAIMessage{Role: m.Role, Content: m.Content})
+	}
+
+	return r
+}
+
+func (o *OpenAI) Chat(ctx context.Context, req *api.ChatRequest, fn api.ChatResponseFunc) error {
+	body, err := json.Marshal(newOpenAIRequest(req))
+	if err != nil {
+		return err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, o.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if o.APIKey != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+o.APIKey)
+	}
+
+	started := time.Now()
+
+	httpResponse, err := o.HTTP.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(httpResponse.Body, 4096))
+		return fmt.Errorf("openai backend: %s: %s", httpResponse.Status, strings.TrimSpace(string(message)))
+	}
+
+	if req.Stream != nil && *req.Stream {
+		return o.stream(httpResponse.Body, started, fn)
+	}
+
+	var response openAIResponse
+	if err := json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		return fmt.Errorf("openai backend: error decoding response: %w", err)
+	}
+
+	if len(response.Choices) == 0 {
+		return fmt.Errorf("openai backend: response has no choices")
+	}
+
+	final := openAIDone(response.Model, response.Choices[0].Message.Content, response.Choices[0].FinishReason, response.Usage, started)
+	return fn(final)
+}
+
+// stream relays a server-sent event stream of completion chunks to fn.
+func (o *OpenAI) stream(body io.Reader, started time.Time, fn api.ChatResponseFunc) error {
+	var (
+		model, finishReason string
+		usage               *openAIUsage
+	)
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("openai backend: error decoding stream: %w", err)
+		}
+
+		model = cmp.Or(chunk.Model, model)
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		finishReason = cmp.Or(chunk.Choices[0].FinishReason, finishReason)
+		if content := chunk.Choices[0].Delta.Content; content != "" {
+			if err := fn(api.ChatResponse{
+				Model:   model,
+				Message: api.Message{Role: assistantRole, Content: content},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return fn(openAIDone(model, "", finishReason, usage, started))
+}
+
+// openAIDone builds the final response of a chat, carrying whatever metrics the
+// server reported.
+func openAIDone(model, content, finishReason string, usage *openAIUsage, started time.Time) api.ChatResponse {
+	response := api.ChatResponse{
+		Model:      model,
+		CreatedAt:  time.Now(),
+		Message:    api.Message{Role: assistantRole, Content: content},
+		Done:       true,
+		DoneReason: finishReason,
+	}
+
+	response.TotalDuration = time.Since(started)
+	if usage != nil {
+		response.PromptEvalCount = usage.PromptTokens
+		response.EvalCount = usage.CompletionTokens
+	}
+
+	return response
+}
@@ -0,0 +1,134 @@
+package dialogue
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+)
+
+// openAIServer stands in for a chat completions endpoint, checking each
+// request before answering it with respond.
+func openAIServer(t *testing.T, stream bool, respond func(w http.ResponseWriter)) *OpenAI {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request = %s %s, want POST /v1/chat/completions", r.Method, r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Authorization = %q, want Bearer secret", auth)
+		}
+
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("error decoding request: %v", err)
+		}
+		if req.Model != "gpt-test" || req.Stream != stream || len(req.Messages) != 2 || req.Messages[1].Content != "Hello" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		if stream && (req.StreamOptions == nil || !req.StreamOptions.IncludeUsage) {
+			t.Error("streaming request does not ask for usage")
+		}
+
+		respond(w)
+	}))
+	t.Cleanup(server.Close)
+
+	return &OpenAI{BaseURL: server.URL + "/v1", APIKey: "secret", HTTP: server.Client()}
+}
+
+func openAIChatRequest(stream bool) *api.ChatRequest {
+	return &api.ChatRequest{
+		Model: "gpt-test",
+		Messages: []api.Message{
+			{Role: systemRole, Content: "Be brief."},
+			{Role: userRole, Content: "Hello"},
+		},
+		Stream: &stream,
+	}
+}
+
+// collect gathers every response passed to a ChatResponseFunc.
+func collect(responses *[]api.ChatResponse) api.ChatResponseFunc {
+	return func(cr api.ChatResponse) error {
+		*responses = append(*responses, cr)
+		return nil
+	}
+}
+
+func TestOpenAIChat(t *testing.T) {
+	backend := openAIServer(t, false, func(w http.ResponseWriter) {
+		fmt.Fprint(w, `{"model":"gpt-test","choices":[{"message":{"role":"assistant","content":"Hi there."},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`)
+	})
+
+	var responses []api.ChatResponse
+	if err := backend.Chat(t.Context(), openAIChatRequest(false), collect(&responses)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(responses))
+	}
+	r := responses[0]
+	if !r.Done || r.Message.Content != "Hi there." || r.DoneReason != "stop" || r.Model != "gpt-test" {
+		t.Errorf("unexpected response: %+v", r)
+	}
+	if r.PromptEvalCount != 12 || r.EvalCount != 3 {
+		t.Errorf("tokens = %d prompt, %d generated, want 12, 3", r.PromptEvalCount, r.EvalCount)
+	}
+}
+
+func TestOpenAIChatStream(t *testing.T) {
+	backend := openAIServer(t, true, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"model":"gpt-test","choices":[{"delta":{"role":"assistant","content":""}}]}`,
+			`{"model":"gpt-test","choices":[{"delta":{"content":"Hi "}}]}`,
+			`{"model":"gpt-test","choices":[{"delta":{"content":"there."},"finish_reason":"stop"}]}`,
+			`{"model":"gpt-test","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3}}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+	})
+
+	var responses []api.ChatResponse
+	if err := backend.Chat(t.Context(), openAIChatRequest(true), collect(&responses)); err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks []string
+	for _, r := range responses[:len(responses)-1] {
+		if r.Done {
+			t.Errorf("chunk marked done early: %+v", r)
+		}
+		chunks = append(chunks, r.Message.Content)
+	}
+	if want := []string{"Hi ", "there."}; !slices.Equal(chunks, want) {
+		t.Errorf("chunks = %q, want %q", chunks, want)
+	}
+
+	final := responses[len(responses)-1]
+	if !final.Done || final.Message.Content != "" || final.DoneReason != "stop" {
+		t.Errorf("unexpected final response: %+v", final)
+	}
+	if final.PromptEvalCount != 12 || final.EvalCount != 3 {
+		t.Errorf("tokens = %d prompt, %d generated, want 12, 3", final.PromptEvalCount, final.EvalCount)
+	}
+}
+
+func TestOpenAIChatError(t *testing.T) {
+	backend := openAIServer(t, false, func(w http.ResponseWriter) {
+		http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+	})
+
+	err := backend.Chat(t.Context(), openAIChatRequest(false), func(api.ChatResponse) error { return nil })
+	if want := "openai backend: 503 Service Unavailable: model not loaded"; err == nil || err.Error() != want {
+		t.Errorf("Chat() = %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,100 @@
+package dialogue
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// golden compares got with the contents of testdata/name, rewriting the
+// file instead when run with -update.
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file (run go test -update to accept):\n%s", path, got)
+	}
+}
+
+func TestSystemPrompts(t *testing.T) {
+	var out strings.Builder
+	c := newMockDialogue(t, 0, &out)
+	c.Roles = []string{"The host", "The guest"}
+	c.Secrets = []string{"Steer the talk to cheese."}
+	c.ExtraPrompts = []string{"Keep it short."}
+	c.Personas[0].Persona = "Ann hosts a late-night chat show."
+	c.Personas[1].Persona = "Bob is a cheesemonger."
+	c.Personas[1].Prompts = []string{"You are nervous."}
+
+	tests := []struct {
+		name  string
+		botID BotID
+		turn  int
+	}{
+		{"persona1-opening", Persona1, 0},
+		{"persona2-turn1", Persona2, 1},
+		{"persona1-reminder", Persona1, reminderInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			for i, prompt := range c.SystemPrompts(tt.botID, tt.turn) {
+				fmt.Fprintf(&b, "--- system prompt %d ---\n%s\n", i+1, prompt)
+			}
+			golden(t, filepath.Join("prompts", tt.name+".golden"), b.String())
+		})
+	}
+}
+
+func TestFromPerspective(t *testing.T) {
+	var out strings.Builder
+	c := newMockDialogue(t, 0, &out)
+	c.state = Running
+	for range 3 {
+		if _, err := c.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		botID   BotID
+		summary string
+	}{
+		{"persona1", Persona1, ""},
+		{"persona2", Persona2, ""},
+		{"persona2-summarised", Persona2, "Ann and Bob have been exchanging lorem ipsum."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.summary = tt.summary
+			var b strings.Builder
+			for _, m := range c.FromPerspective(tt.botID).Messages {
+				fmt.Fprintf(&b, "--- %s ---\n%s\n", m.Role, m.Content)
+			}
+			golden(t, filepath.Join("perspective", tt.name+".golden"), b.String())
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package dialogue
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/isometry/yaketty/internal/cassette"
+	"github.com/isometry/yaketty/internal/config"
+	"github.com/isometry/yaketty/internal/library"
+	"github.com/isometry/yaketty/internal/options"
+	"github.com/isometry/yaketty/internal/output"
+)
+
+// scenarioTurns is how many turns of each embedded scenario are exercised.
+const scenarioTurns = 4
+
+// The embedded library is only attached to the binary, so tests read the
+// same files from the top of the repository.
+var (
+	scenarioLibrary = filepath.Join("..", "..", library.LibraryTypeScenario)
+	personaLibrary  = filepath.Join("..", "..", library.LibraryTypePersona)
+)
+
+// newScenarioDialogue loads the embedded scenario name with every seat on
+// the lorem mock, rendering to out.
+func newScenarioDialogue(t *testing.T, name string, out *strings.Builder) *Dialogue {
+	t.Helper()
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("persona_library", personaLibrary)
+
+	cfg, err := config.Load(".", filepath.Join(scenarioLibrary, name+".yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.OverrideBackend(options.BackendOptions{Type: "mock", Script: "lorem"})
+	cfg.Limits.MaxTurns = scenarioTurns
+
+	c, err := NewDialogue(t.Context(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Output = output.Text{W: out}
+	return c
+}
+
+// embeddedScenarios lists the scenarios built into the binary.
+func embeddedScenarios(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(scenarioLibrary, "*.yaml"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no scenarios in %s: %v", scenarioLibrary, err)
+	}
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = strings.TrimSuffix(filepath.Base(file), ".yaml")
+	}
+	return names
+}
+
+// formatRequests renders every request on tape, in the order it was made.
+func formatRequests(tape *cassette.Cassette) string {
+	var b strings.Builder
+	for _, i := range tape.Interactions {
+		fmt.Fprintf(&b, "=== %s, request %d (%s) ===\n", i.Persona, i.Turn, i.Request.Model)
+		for _, m := range i.Request.Messages {
+			fmt.Fprintf(&b, "--- %s ---\n%s\n", m.Role, m.Content)
+		}
+	}
+	return b.String()
+}
+
+func TestEmbeddedScenarioPrompts(t *testing.T) {
+	for _, name := range embeddedScenarios(t) {
+		t.Run(name, func(t *testing.T) {
+			var out strings.Builder
+			c := newScenarioDialogue(t, name, &out)
+
+			path := filepath.Join(t.TempDir(), "cassette.yaml")
+			c.Record(path)
+			if err := c.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			tape, err := cassette.Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, filepath.Join("scenarios", name+".golden"), formatRequests(tape))
+		})
+	}
+}
+
+func TestEmbeddedScenarioReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	var recorded strings.Builder
+	rec := newScenarioDialogue(t, "museum-heist", &recorded)
+	rec.Record(path)
+	if err := rec.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var replayed strings.Builder
+	rep := newScenarioDialogue(t, "museum-heist", &replayed)
+	if err := rep.Replay(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := rep.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rep.Messages) != scenarioTurns {
+		t.Fatalf("replayed %d turns, want %d", len(rep.Messages), scenarioTurns)
+	}
+	if got, want := speakers(rep), speakers(rec); !slices.Equal(got, want) {
+		t.Errorf("replayed speakers = %v, recorded %v", got, want)
+	}
+	for i, m := range rep.Messages {
+		if m.content != rec.Messages[i].content {
+			t.Errorf("turn %d replayed %q, recorded %q", i, m.content, rec.Messages[i].content)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package dialogue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/output"
+)
+
+// State describes where a Dialogue is in its lifecycle.
+type State int
+
+const (
+	Idle State = iota
+	Running
+	Paused
+	Stopped
+	Finished
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Running:
+		return "running"
+	case Paused:
+		return "paused"
+	case Stopped:
+		return "stopped"
+	case Finished:
+		return "finished"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// State returns the current lifecycle state of the dialogue.
+func (c *Dialogue) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Next returns the persona that will speak on the next turn.
+func (c *Dialogue) Next() BotID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.next
+}
+
+// Turn returns the zero-based index of the next turn.
+func (c *Dialogue) Turn() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Messages)
+}
+
+// Pause suspends the dialogue before the next turn begins.
+// A turn already in flight is allowed to complete.
+func (c *Dialogue) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == Running {
+		c.state = Paused
+		c.resumed = make(chan struct{})
+	}
+}
+
+// Resume continues a paused dialogue.
+func (c *Dialogue) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == Paused {
+		c.state = Running
+		close(c.resumed)
+	}
+}
+
+// Stop ends the dialogue, abandoning any turn in flight.
+func (c *Dialogue) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == Paused {
+		close(c.resumed)
+	}
+	if c.state != Finished {
+		c.state = Stopped
+	}
+	if c.cancelTurn != nil {
+		c.cancelTurn()
+	}
+}
+
+// Skip abandons the turn in flight and moves on to the next speaker.
+func (c *Dialogue) Skip() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancelTurn != nil {
+		c.skipped = true
+		c.cancelTurn()
+	}
+}
+
+// waitWhilePaused blocks until the dialogue is no longer paused, returning
+// false if it has been stopped in the meantime.
+func (c *Dialogue) waitWhilePaused() (bool, error) {
+	c.mu.Lock()
+	state, resumed := c.state, c.resumed
+	c.mu.Unlock()
+
+	if state == Paused {
+		select {
+		case <-resumed:
+		case <-c.ctx.Done():
+			return false, c.ctx.Err()
+		}
+	}
+
+	return c.State() != Stopped, nil
+}
+
+// Step runs a single turn for the next persona, returning true once the
+// dialogue has nothing more to say.
+func (c *Dialogue) Step() (bool, error) {
+	botID := c.Next()
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	c.mu.Lock()
+	c.cancelTurn, c.skipped = cancel, false
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.cancelTurn = nil
+		c.mu.Unlock()
+	}()
+
+	var (
+		response api.ChatResponse
+		streamed bool
+		latency  time.Duration
+		err      error
+		// sent counts the director's notes delivered with this turn
+		sent int
+	)
+
+	if c.speaker(botID).Human {
+		sent = len(c.notesFor(botID))
+		if response.Message.Content, err = c.ask(ctx, botID); errors.Is(err, errInputClosed) {
+			return true, nil
+		}
+	} else {
+		if c.Turn() > 0 {
+			if err := c.summarise(ctx, botID); err != nil {
+				slog.Warn("failed to summarise older messages", slog.Any("error", err))
+			}
+		}
+
+		sent = len(c.notesFor(botID))
+
+		var chatRequest api.ChatRequest
+		if c.Turn() == 0 {
+			chatRequest = c.OpeningRequest()
+			slog.Debug("sending opening request", slog.String("perspective", c.Personas[botID].Name), slog.Any("chatRequest", chatRequest))
+		} else {
+			chatRequest = c.FromPerspective(botID)
+			slog.Debug("sending chat request", slog.String("perspective", c.speaker(botID).Name), slog.Any("chatRequest", chatRequest))
+		}
+
+		requested := time.Now()
+		response, streamed, err = c.chat(ctx, botID, &chatRequest)
+		latency = time.Since(requested)
+	}
+
+	if err != nil && ctx.Err() != nil && c.ctx.Err() == nil {
+		// The turn was interrupted rather than failing
+		c.mu.Lock()
+		skipped := c.skipped
+		c.mu.Unlock()
+
+		if c.State() == Stopped {
+			return true, nil
+		}
+		if skipped {
+			slog.Info("skipped turn", slog.String("persona", c.speaker(botID).Name))
+			return false, c.advance(botID)
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	message := strings.TrimSpace(response.Message.Content)
+	if len(message) == 0 && len(c.Messages) > 0 {
+		if len(c.Messages[len(c.Messages)-1].content) == 0 {
+			return true, nil
+		}
+		message = "..."
+	}
+
+	m := &Message{
+		persona: botID,
+		content: message,
+		model:   c.speaker(botID).Model,
+		time:    time.Now(),
+		metrics: response.Metrics,
+		latency: latency,
+	}
+
+	if streamed {
+		c.Output.(output.Streamer).EndTurn(c.outputMessage(m))
+		c.appendMessage(m)
+	} else {
+		c.AddMessage(m)
+	}
+
+	c.status(m)
+	c.clearNotes(botID, sent)
+
+	if c.SavePath != "" {
+		if err := c.Transcript().Save(c.SavePath); err != nil {
+			return false, fmt.Errorf("error saving transcript: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.Usage.Turns++
+	c.Usage.Tokens += response.EvalCount
+	c.Usage.Elapsed = time.Since(c.started)
+	c.mu.Unlock()
+
+	return false, c.advance(botID)
+}
+
+// advance hands the floor on from botID, fitting in the moderator when due.
+func (c *Dialogue) advance(botID BotID) error {
+	var next BotID
+	if botID == Moderator {
+		next = c.afterModerator
+	} else {
+		var err error
+		if next, err = c.Order.Next(c, botID); err != nil {
+			return fmt.Errorf("error choosing next speaker: %w", err)
+		}
+
+		if c.moderatorDue() {
+			c.afterModerator, next = next, Moderator
+		}
+	}
+
+	c.mu.Lock()
+	c.next = next
+	c.mu.Unlock()
+
+	return nil
+}
+
+// chat sends a request on behalf of botID and returns the complete response.
+// When streaming to an output.Streamer, chunks are rendered as they arrive and
+// streamed reports whether any were, leaving the caller to end the turn;
+// otherwise the caller renders the message.
+func (c *Dialogue) chat(ctx context.Context, botID BotID, chatRequest *api.ChatRequest) (response api.ChatResponse, streamed bool, err error) {
+	streamer, canStream := c.Output.(output.Streamer)
+	canStream = canStream && c.Stream
+
+	var content strings.Builder
+	err = c.backend(botID).Chat(ctx, chatRequest, func(cr api.ChatResponse) error {
+		chunk := cr.Message.Content
+		content.WriteString(chunk)
+
+		if canStream {
+			if !streamed {
+				// Hold back leading whitespace until the first words arrive
+				chunk = strings.TrimLeftFunc(content.String(), unicode.IsSpace)
+				if chunk == "" {
+					return nil
+				}
+				streamer.StartTurn(c.outputMessage(&Message{persona: botID, model: c.speaker(botID).Model, time: time.Now()}))
+				streamed = true
+			}
+			streamer.Delta(chunk)
+		}
+
+		if cr.Done {
+			response = cr
+		}
+		return nil
+	})
+
+	if streamed && err != nil {
+		// The turn was abandoned, so there is no message to complete it with
+		streamer.EndTurn(c.outputMessage(&Message{persona: botID, model: c.speaker(botID).Model, time: time.Now()}))
+	}
+
+	response.Message.Content = content.String()
+	return response, streamed, err
+}
+
+// Start runs the dialogue turn by turn until it is stopped, a limit is
+// reached, or the model has nothing more to say.
+func (c *Dialogue) Start() error {
+	c.mu.Lock()
+	c.state = Running
+	c.started = time.Now()
+	c.mu.Unlock()
+
+	if titled, ok := c.Output.(output.Titled); ok {
+		titled.Begin(c.title())
+	}
+
+	if c.Limits.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		c.ctx, cancel = context.WithTimeoutCause(c.ctx, c.Limits.MaxDuration, errDurationLimit)
+		defer cancel()
+	}
+
+	reason, err := c.run()
+	switch {
+	case errors.Is(context.Cause(c.ctx), errDurationLimit):
+		// The in-flight request was cut short by the duration limit; this is a clean end
+		reason, err = c.Limits.Exceeded(c.Usage.WithElapsed(c.Limits.MaxDuration)), nil
+	case c.interrupted():
+		// Wrap up as for any other ending, but let the caller know
+		reason, err = "interrupted", ErrInterrupted
+	}
+
+	c.mu.Lock()
+	if c.state != Stopped {
+		c.state = Finished
+	}
+	c.mu.Unlock()
+
+	if err != nil && !errors.Is(err, ErrInterrupted) {
+		return errors.Join(err, c.Output.Close())
+	}
+
+	c.finish(reason)
+
+	if c.SavePath != "" {
+		if err := c.Transcript().Save(c.SavePath); err != nil {
+			return errors.Join(fmt.Errorf("error saving transcript: %w", err), c.Output.Close())
+		}
+	}
+
+	return errors.Join(err, c.Output.Close())
+}
+
+// interrupted reports whether the dialogue's context was cancelled from
+// outside, such as by a signal, rather than by its duration limit.
+func (c *Dialogue) interrupted() bool {
+	return c.ctx.Err() != nil && !errors.Is(context.Cause(c.ctx), errDurationLimit)
+}
+
+// run is the turn loop, returning the reason the dialogue ended.
+func (c *Dialogue) run() (string, error) {
+	for {
+		if ok, err := c.waitWhilePaused(); err != nil {
+			return "", err
+		} else if !ok {
+			return "stopped", nil
+		}
+
+		if reason := c.Limits.Exceeded(c.Usage); reason != "" {
+			return reason, nil
+		}
+
+		done, err := c.Step()
+		if errors.Is(err, errEndOfScript) {
+			return "end of script", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if c.State() == Stopped {
+			return "stopped", nil
+		}
+		if done {
+			return "nothing more to say", nil
+		}
+	}
+}
+
+// finish reports why the dialogue ended along with the resources it consumed.
+func (c *Dialogue) finish(reason string) {
+	c.Usage.Elapsed = time.Since(c.started)
+	c.Output.Summary(fmt.Sprintf("Dialogue ended: %s (%s)", reason, c.Usage))
+	c.runSummary()
+	c.reveal()
+}
@@ -0,0 +1,143 @@
+package dialogue
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ollama/ollama/api"
+)
+
+// stall holds the first request made to it until the request is cancelled,
+// answering every later one from backend.
+type stall struct {
+	backend Backend
+	stalled chan struct{}
+	calls   int
+}
+
+func (s *stall) Chat(ctx context.Context, req *api.ChatRequest, fn api.ChatResponseFunc) error {
+	s.calls++
+	if s.calls > 1 {
+		return s.backend.Chat(ctx, req, fn)
+	}
+	close(s.stalled)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// stallSecond makes the second persona's first turn hang until cancelled.
+func stallSecond(c *Dialogue) *stall {
+	s := &stall{backend: c.backends[Persona2], stalled: make(chan struct{})}
+	c.backends[Persona2] = s
+	return s
+}
+
+// startAsync runs the dialogue, returning a channel that receives its result.
+func startAsync(c *Dialogue) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- c.Start() }()
+	return done
+}
+
+// wait receives from ch, failing if nothing arrives in good time.
+func wait[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+	}
+	t.Fatalf("timed out waiting for %s", what)
+	var zero T
+	return zero
+}
+
+func TestStartTakesTurnsUntilLimit(t *testing.T) {
+	var out strings.Builder
+	c := newMockDialogue(t, 4, &out)
+
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := speakers(c), []BotID{Persona1, Persona2, Persona1, Persona2}; !slices.Equal(got, want) {
+		t.Errorf("speakers = %v, want %v", got, want)
+	}
+	if state := c.State(); state != Finished {
+		t.Errorf("state = %s, want finished", state)
+	}
+	if c.Usage.Turns != 4 {
+		t.Errorf("usage turns = %d, want 4", c.Usage.Turns)
+	}
+	if !strings.Contains(out.String(), "Dialogue ended: reached maximum of 4 turns") {
+		t.Errorf("output has no ending summary:\n%s", out.String())
+	}
+}
+
+func TestStepRendersEachTurn(t *testing.T) {
+	var out strings.Builder
+	c := newMockDialogue(t, 0, &out)
+	c.state = Running
+
+	for range 2 {
+		if done, err := c.Step(); err != nil || done {
+			t.Fatalf("Step() = %v, %v", done, err)
+		}
+	}
+
+	if got, want := speakers(c), []BotID{Persona1, Persona2}; !slices.Equal(got, want) {
+		t.Errorf("speakers = %v, want %v", got, want)
+	}
+	if !strings.HasPrefix(out.String(), "Ann: Lorem ipsum") || !strings.Contains(out.String(), "\n\nBob: ") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestSkipAbandonsTurnInFlight(t *testing.T) {
+	var out strings.Builder
+	c := newMockDialogue(t, 3, &out)
+	s := stallSecond(c)
+
+	done := startAsync(c)
+	wait(t, s.stalled, "second persona's turn")
+	c.Skip()
+
+	if err := wait(t, done, "dialogue to end"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bob's skipped turn passes the floor back to Ann, then Bob answers
+	if got, want := speakers(c), []BotID{Persona1, Persona1, Persona2}; !slices.Equal(got, want) {
+		t.Errorf("speakers = %v, want %v", got, want)
+	}
+	if state := c.State(); state != Finished {
+		t.Errorf("state = %s, want finished", state)
+	}
+}
+
+func TestStopAbandonsTurnInFlight(t *testing.T) {
+	var out strings.Builder
+	c := newMockDialogue(t, 0, &out)
+	s := stallSecond(c)
+
+	done := startAsync(c)
+	wait(t, s.stalled, "second persona's turn")
+	c.Stop()
+
+	if err := wait(t, done, "dialogue to end"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := speakers(c), []BotID{Persona1}; !slices.Equal(got, want) {
+		t.Errorf("speakers = %v, want %v", got, want)
+	}
+	if state := c.State(); state != Stopped {
+		t.Errorf("state = %s, want stopped", state)
+	}
+	if !strings.Contains(out.String(), "Dialogue ended: stopped") {
+		t.Errorf("output has no ending summary:\n%s", out.String())
+	}
+}
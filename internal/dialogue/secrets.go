@@ -0,0 +1,92 @@
+package dialogue
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/output"
+)
+
+const (
+	// Gives a persona its hidden objective
+	secretPrompt = `You have a secret objective that the other characters must not learn: %s
+		 Pursue it through what you say, but never reveal or state it outright.`
+
+	// Asks the judge whether a secret objective was achieved
+	judgePrompt = `You are judging the conversation that follows.
+		 %s had a secret objective: %s
+		 Did they achieve it? Answer "Achieved" or "Not achieved", followed by one sentence explaining why.`
+)
+
+// secret returns the secret objective for botID, if the scenario gives it one.
+func (c *Dialogue) secret(botID BotID) string {
+	if botID >= 0 && int(botID) < len(c.Secrets) {
+		return c.Secrets[botID]
+	}
+	return ""
+}
+
+// script returns messages as plain text, each prefixed with its speaker's name.
+func (c *Dialogue) script(messages []*Message) string {
+	var script strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&script, "%s: %s\n\n", c.speaker(msg.persona).Name, msg.content)
+	}
+	return script.String()
+}
+
+// reveal discloses each persona's secret, judged if the scenario asks for it.
+func (c *Dialogue) reveal() {
+	for i, p := range c.Personas {
+		secret := c.secret(BotID(i))
+		if secret == "" {
+			continue
+		}
+
+		r := output.Reveal{Name: p.Name, Secret: secret}
+		if c.Reveal.Judge && !c.interrupted() {
+			verdict, err := c.judge(BotID(i))
+			if err != nil {
+				slog.Warn("failed to judge secret", slog.String("persona", p.Name), slog.Any("error", err))
+			}
+			r.Verdict = verdict
+		}
+
+		c.Output.Reveal(r)
+	}
+}
+
+// judge asks a model whether botID achieved its secret objective.
+func (c *Dialogue) judge(botID BotID) (string, error) {
+	helper, err := c.helper(botID)
+	if err != nil {
+		return "", err
+	}
+
+	chatRequest := api.ChatRequest{
+		Model: cmp.Or(c.Reveal.Model, c.speaker(helper).Model),
+		Messages: append(
+			systemMessages(c.Scenario.Scenario, fmt.Sprintf(judgePrompt, c.speaker(botID).Name, c.secret(botID))),
+			newMessage(userRole, c.script(c.Messages)),
+		),
+		Stream: new(bool),
+	}
+
+	// The dialogue's context may already have expired with its duration limit
+	ctx := context.WithoutCancel(c.ctx)
+
+	var verdict string
+	if err := c.backend(helper).Chat(helperContext(ctx), &chatRequest, func(cr api.ChatResponse) error {
+		verdict += cr.Message.Content
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(verdict), nil
+}
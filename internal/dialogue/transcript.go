@@ -0,0 +1,79 @@
+package dialogue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/transcript"
+)
+
+// Transcript captures the dialogue configuration and messages for saving.
+func (c *Dialogue) Transcript() *transcript.Transcript {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &transcript.Transcript{
+		Config:   *c.config,
+		Messages: make([]transcript.Entry, 0, len(c.Messages)),
+	}
+
+	for _, m := range c.Messages {
+		t.Messages = append(t.Messages, transcript.Entry{
+			Persona: int(m.persona),
+			Speaker: c.speaker(m.persona).Name,
+			Model:   m.model,
+			Time:    m.time,
+			Content: m.content,
+
+			PromptTokens: m.metrics.PromptEvalCount,
+			Tokens:       m.metrics.EvalCount,
+			EvalDuration: m.metrics.EvalDuration,
+			Latency:      m.latency,
+		})
+	}
+
+	return t
+}
+
+// Resume recreates a dialogue from a saved transcript, ready to continue with
+// whichever persona is due to speak next.
+func Resume(ctx context.Context, t *transcript.Transcript) (*Dialogue, error) {
+	t.Config.SeatLegacyPersonas()
+
+	c, err := NewDialogue(ctx, &t.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range t.Messages {
+		if (e.Persona < 0 || e.Persona >= len(c.Personas)) && (BotID(e.Persona) != Moderator || c.Moderator == nil) {
+			return nil, fmt.Errorf("transcript message %d has invalid persona %d", i, e.Persona)
+		}
+		c.Messages = append(c.Messages, &Message{
+			persona: BotID(e.Persona),
+			content: e.Content,
+			model:   e.Model,
+			time:    e.Time,
+			metrics: api.Metrics{
+				PromptEvalCount: e.PromptTokens,
+				EvalCount:       e.Tokens,
+				EvalDuration:    e.EvalDuration,
+			},
+			latency: e.Latency,
+		})
+	}
+
+	// Continue from the last participant to speak, skipping any closing narration
+	for i := len(c.Messages) - 1; i >= 0; i-- {
+		if last := c.Messages[i].persona; last != Moderator {
+			if c.next, err = c.Order.Next(c, last); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,235 @@
+package dialogue
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/isometry/yaketty/internal/scenario"
+)
+
+// TurnOrder decides which persona speaks after last.
+type TurnOrder interface {
+	Next(c *Dialogue, last BotID) (BotID, error)
+}
+
+// TurnOrderFactory builds a TurnOrder from its scenario configuration.
+type TurnOrderFactory func(cfg scenario.TurnOrder) (TurnOrder, error)
+
+var (
+	turnOrdersMu sync.RWMutex
+	turnOrders   = map[string]TurnOrderFactory{
+		"round-robin":       func(scenario.TurnOrder) (TurnOrder, error) { return RoundRobin{}, nil },
+		"random":            func(cfg scenario.TurnOrder) (TurnOrder, error) { return &Weighted{rng: newRand(cfg.Seed)}, nil },
+		"weighted":          newWeighted,
+		"mentioned":         func(scenario.TurnOrder) (TurnOrder, error) { return Mentioned{}, nil },
+		"moderator-decides": func(cfg scenario.TurnOrder) (TurnOrder, error) { return ModeratorDecides{Model: cfg.Model}, nil },
+	}
+
+	moderatorDecidesPrompt = `You are moderating the conversation that follows.
+		 Based on the conversation so far, decide who should speak next to keep it lively and on topic.
+		 Choose one of: %s. Answer with exactly one of those names and nothing else.`
+)
+
+// RegisterTurnOrder makes a turn-taking strategy available to scenarios under name.
+func RegisterTurnOrder(name string, factory TurnOrderFactory) {
+	turnOrdersMu.Lock()
+	defer turnOrdersMu.Unlock()
+	turnOrders[name] = factory
+}
+
+// NewTurnOrder builds the turn-taking strategy named in cfg.
+func NewTurnOrder(cfg scenario.TurnOrder) (TurnOrder, error) {
+	name := cmp.Or(cfg.Strategy, "round-robin")
+
+	turnOrdersMu.RLock()
+	factory, ok := turnOrders[name]
+	turnOrdersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown turn order strategy: %s", name)
+	}
+
+	return factory(cfg)
+}
+
+func newRand(seed uint64) *rand.Rand {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// RoundRobin has personas speak in the order they are seated.
+type RoundRobin struct{}
+
+func (RoundRobin) Next(c *Dialogue, last BotID) (BotID, error) {
+	return c.following(last), nil
+}
+
+// Weighted picks anyone but the last speaker at random, in proportion to
+// their weight. Personas without a weight count as 1, so with no weights at
+// all every other persona is equally likely.
+type Weighted struct {
+	Weights []float64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newWeighted(cfg scenario.TurnOrder) (TurnOrder, error) {
+	for i, w := range cfg.Weights {
+		if w < 0 {
+			return nil, fmt.Errorf("turn order weight %d must not be negative: %v", i+1, w)
+		}
+	}
+	return &Weighted{Weights: cfg.Weights, rng: newRand(cfg.Seed)}, nil
+}
+
+func (w *Weighted) weight(botID BotID) float64 {
+	if int(botID) < len(w.Weights) {
+		return w.Weights[botID]
+	}
+	return 1
+}
+
+func (w *Weighted) Next(c *Dialogue, last BotID) (BotID, error) {
+	var total float64
+	for i := range c.Personas {
+		if BotID(i) != last {
+			total += w.weight(BotID(i))
+		}
+	}
+
+	if total == 0 {
+		return c.following(last), nil
+	}
+
+	w.mu.Lock()
+	pick := w.rng.Float64() * total
+	w.mu.Unlock()
+
+	for i := range c.Personas {
+		if BotID(i) == last {
+			continue
+		}
+		if pick -= w.weight(BotID(i)); pick < 0 {
+			return BotID(i), nil
+		}
+	}
+
+	return c.following(last), nil
+}
+
+// Mentioned hands the floor to whoever the last speaker addressed by name,
+// falling back to round-robin when nobody was.
+type Mentioned struct{}
+
+func (Mentioned) Next(c *Dialogue, last BotID) (BotID, error) {
+	if len(c.Messages) == 0 {
+		return c.following(last), nil
+	}
+
+	content := c.Messages[len(c.Messages)-1].content
+
+	next, at := c.following(last), -1
+	for i, p := range c.Personas {
+		if BotID(i) == last {
+			continue
+		}
+		// The most recent mention wins: "Carl, what do you think, Robin?" goes to Robin
+		if pos := lastMention(content, p.Name); pos > at {
+			next, at = BotID(i), pos
+		}
+	}
+
+	return next, nil
+}
+
+// lastMention returns the offset of the last place name, or any part of it,
+// appears as a whole word in content, or -1 if it doesn't.
+func lastMention(content, name string) int {
+	at := -1
+	for _, part := range append([]string{name}, strings.Fields(name)...) {
+		if len(part) < 3 {
+			continue
+		}
+		re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(part) + `\b`)
+		if matches := re.FindAllStringIndex(content, -1); len(matches) > 0 {
+			at = max(at, matches[len(matches)-1][0])
+		}
+	}
+	return at
+}
+
+// ModeratorDecides asks a model to choose the next speaker, falling back to
+// round-robin if its answer doesn't name one of the other personas.
+type ModeratorDecides struct {
+	// Model defaults to the last speaker's model, or another's if they are human
+	Model string
+}
+
+func (m ModeratorDecides) Next(c *Dialogue, last BotID) (BotID, error) {
+	names := make([]string, 0, len(c.Personas)-1)
+	for i, p := range c.Personas {
+		if BotID(i) != last {
+			names = append(names, p.Name)
+		}
+	}
+
+	if len(names) == 1 {
+		return c.following(last), nil
+	}
+
+	helper, err := c.helper(last)
+	if err != nil {
+		return 0, err
+	}
+
+	chatRequest := api.ChatRequest{
+		Model: cmp.Or(m.Model, c.speaker(helper).Model),
+		Messages: append(
+			systemMessages(c.Scenario.Scenario, fmt.Sprintf(moderatorDecidesPrompt, strings.Join(names, ", "))),
+			newMessage(userRole, c.script(c.Messages)),
+		),
+		Stream: new(bool),
+	}
+
+	var answer string
+	if err := c.backend(helper).Chat(helperContext(c.ctx), &chatRequest, func(cr api.ChatResponse) error {
+		answer += cr.Message.Content
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	answer = strings.TrimSpace(answer)
+	for i, p := range c.Personas {
+		if BotID(i) != last && strings.EqualFold(strings.Trim(answer, ".*\"' "), p.Name) {
+			return BotID(i), nil
+		}
+	}
+
+	// Tolerate chatty answers by looking for the name anywhere
+	at, next := -1, c.following(last)
+	for i, p := range c.Personas {
+		if BotID(i) == last {
+			continue
+		}
+		if pos := lastMention(answer, p.Name); pos > at {
+			at, next = pos, BotID(i)
+		}
+	}
+
+	if at < 0 {
+		slog.Warn("moderator did not choose a valid speaker", slog.String("answer", answer))
+	}
+
+	return next, nil
+}
@@ -100,11 +100,17 @@ func listFiles(libraryType string) ([]string, error) {
 
 // IsDirectPath returns true if the path should be treated as a direct file path.
 // Rules:
+// - Spans multiple lines → inline text, not a path
 // - Is absolute path → direct path
 // - Contains path separator → direct path
 // - Has .yaml extension AND file exists → direct path
 // - Otherwise → library reference
 func IsDirectPath(path string) bool {
+	// Multi-line values are inline scenario or persona text
+	if strings.Contains(path, "\n") {
+		return false
+	}
+
 	// Absolute paths or paths with separators are always direct
 	if filepath.IsAbs(path) || strings.Contains(path, string(filepath.Separator)) {
 		return true
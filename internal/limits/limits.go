@@ -0,0 +1,44 @@
+package limits
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits bounds the length of a dialogue. Zero values mean unlimited.
+type Limits struct {
+	MaxTurns    int           `mapstructure:"max_turns" yaml:"max_turns,omitempty"`
+	MaxDuration time.Duration `mapstructure:"max_duration" yaml:"max_duration,omitempty"`
+	MaxTokens   int           `mapstructure:"max_tokens" yaml:"max_tokens,omitempty"`
+}
+
+// Usage tracks how much of the dialogue budget has been consumed.
+type Usage struct {
+	Turns   int
+	Tokens  int
+	Elapsed time.Duration
+}
+
+// Exceeded returns a human-readable reason if usage has reached any limit,
+// or an empty string if the dialogue may continue.
+func (l Limits) Exceeded(u Usage) string {
+	switch {
+	case l.MaxTurns > 0 && u.Turns >= l.MaxTurns:
+		return fmt.Sprintf("reached maximum of %d turns", l.MaxTurns)
+	case l.MaxTokens > 0 && u.Tokens >= l.MaxTokens:
+		return fmt.Sprintf("reached maximum of %d tokens", l.MaxTokens)
+	case l.MaxDuration > 0 && u.Elapsed >= l.MaxDuration:
+		return fmt.Sprintf("reached maximum duration of %s", l.MaxDuration)
+	}
+	return ""
+}
+
+func (u Usage) String() string {
+	return fmt.Sprintf("%d turns, %d tokens, %s", u.Turns, u.Tokens, u.Elapsed.Round(time.Second))
+}
+
+// WithElapsed returns a copy of the usage with the elapsed time replaced.
+func (u Usage) WithElapsed(elapsed time.Duration) Usage {
+	u.Elapsed = elapsed
+	return u
+}
@@ -0,0 +1,32 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BackendOptions selects the model server that answers chat requests.
+type BackendOptions struct {
+	// Type is the protocol spoken by the server: ollama (default), openai or mock
+	Type string `mapstructure:"type" yaml:"type,omitempty"`
+	// URL is the server's base URL; empty uses the protocol's default
+	URL string `mapstructure:"url" yaml:"url,omitempty"`
+	// APIKeyEnv names the environment variable holding the API key
+	APIKeyEnv string `mapstructure:"api_key_env" yaml:"api_key_env,omitempty"`
+	// Script drives the mock backend: a YAML file of canned lines, echo or lorem
+	Script string `mapstructure:"script" yaml:"script,omitempty"`
+}
+
+// ParseBackend parses a backend given as type[=url], such as
+// "openai=http://localhost:8080/v1". For the mock backend the value after
+// "=" is its script instead, such as "mock=lines.yaml".
+func ParseBackend(spec string) (BackendOptions, error) {
+	backendType, value, _ := strings.Cut(spec, "=")
+	if backendType == "" {
+		return BackendOptions{}, fmt.Errorf("invalid backend %q: expected type[=url]", spec)
+	}
+	if backendType == "mock" {
+		return BackendOptions{Type: backendType, Script: value}, nil
+	}
+	return BackendOptions{Type: backendType, URL: value}, nil
+}
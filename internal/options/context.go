@@ -0,0 +1,35 @@
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ContextOptions decides how much of the dialogue history each request carries.
+type ContextOptions struct {
+	// Strategy is full (default), sliding-window or summarise
+	Strategy string `mapstructure:"strategy" yaml:"strategy" default:"full"`
+	// Window is the number of most recent messages sent verbatim
+	Window int `mapstructure:"window" yaml:"window,omitempty"`
+	// Model writes summaries; empty uses the speaking persona's own model
+	Model string `mapstructure:"model" yaml:"model,omitempty"`
+}
+
+// ContextStrategies are the valid values of ContextOptions.Strategy.
+var ContextStrategies = []string{"full", "sliding-window", "summarise"}
+
+// ParseContext parses a context strategy given as strategy[=window], such as
+// "sliding-window=20".
+func ParseContext(spec string) (ContextOptions, error) {
+	strategy, window, found := strings.Cut(spec, "=")
+	opts := ContextOptions{Strategy: strategy}
+	if found {
+		n, err := strconv.Atoi(window)
+		if err != nil || n < 1 {
+			return ContextOptions{}, fmt.Errorf("invalid context window %q: expected a positive number of messages", window)
+		}
+		opts.Window = n
+	}
+	return opts, nil
+}
@@ -1,13 +1,13 @@
 package options
 
 type ModelOptions struct {
-	NumCtx        int      `mapstructure:"num_ctx" default:"8192"`
-	RepeatLastN   int      `mapstructure:"repeat_last_n" default:"-1"`
-	RepeatPenalty float32  `mapstructure:"repeat_penalty" default:"1.1"`
-	Temperature   float32  `mapstructure:"temperature" default:"0.8"`
-	Stop          []string `mapstructure:"stop"`
-	TopK          int      `mapstructure:"top_k" default:"40"`
-	TopP          float32  `mapstructure:"top_p" default:"0.9"`
+	NumCtx        int      `mapstructure:"num_ctx" yaml:"num_ctx" default:"8192"`
+	RepeatLastN   int      `mapstructure:"repeat_last_n" yaml:"repeat_last_n" default:"-1"`
+	RepeatPenalty float32  `mapstructure:"repeat_penalty" yaml:"repeat_penalty" default:"1.1"`
+	Temperature   float32  `mapstructure:"temperature" yaml:"temperature" default:"0.8"`
+	Stop          []string `mapstructure:"stop" yaml:"stop,omitempty"`
+	TopK          int      `mapstructure:"top_k" yaml:"top_k" default:"40"`
+	TopP          float32  `mapstructure:"top_p" yaml:"top_p" default:"0.9"`
 }
 
 func (o ModelOptions) AsMap() map[string]any {
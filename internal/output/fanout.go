@@ -0,0 +1,158 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Fanout sends the dialogue to several outputs at once. It watches every
+// output's writes, as the styles themselves don't: an output that fails to
+// write is reported and dropped, leaving the others running.
+type Fanout struct {
+	sinks []*sink
+}
+
+// sink is a single output of a Fanout.
+type sink struct {
+	name  string
+	style OutputStyle
+	w     *failWriter
+	file  io.Closer
+}
+
+// failWriter records the first error writing to w, discarding everything
+// written after it.
+type failWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (f *failWriter) Write(p []byte) (int, error) {
+	if f.err != nil {
+		return len(p), nil
+	}
+	n, err := f.w.Write(p)
+	if err != nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// Open returns a Fanout writing to each of specs, given as format[=path]. An
+// output without a path, or with the path "-", is written to stdout.
+func Open(specs []string, stdout *os.File) (*Fanout, error) {
+	f := &Fanout{}
+
+	for _, spec := range specs {
+		format, path, _ := strings.Cut(spec, "=")
+		if _, err := newStyle(format, io.Discard, false); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		file := stdout
+		if path != "" && path != "-" {
+			var err error
+			if file, err = os.Create(path); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("error opening output: %w", err)
+			}
+		}
+
+		w := &failWriter{w: file}
+		style, _ := newStyle(format, w, colorEnabled(file))
+
+		s := &sink{name: spec, style: style, w: w}
+		if file != stdout {
+			s.file = file
+		}
+		f.sinks = append(f.sinks, s)
+	}
+
+	return f, nil
+}
+
+// each calls fn for every working sink, dropping any that fail to write.
+func (f *Fanout) each(fn func(s *sink)) {
+	for _, s := range f.sinks {
+		if s.w.err != nil {
+			continue
+		}
+		fn(s)
+		if s.w.err != nil {
+			slog.Warn("output failed, continuing without it", slog.String("output", s.name), slog.Any("error", s.w.err))
+		}
+	}
+}
+
+func (f *Fanout) Begin(t Title) {
+	f.each(func(s *sink) {
+		if titled, ok := s.style.(Titled); ok {
+			titled.Begin(t)
+		}
+	})
+}
+
+func (f *Fanout) Render(m Message) {
+	f.each(func(s *sink) { s.style.Render(m) })
+}
+
+func (f *Fanout) StartTurn(m Message) {
+	f.each(func(s *sink) {
+		if streamer, ok := s.style.(Streamer); ok {
+			streamer.StartTurn(m)
+		}
+	})
+}
+
+func (f *Fanout) Delta(words string) {
+	f.each(func(s *sink) {
+		if streamer, ok := s.style.(Streamer); ok {
+			streamer.Delta(words)
+		}
+	})
+}
+
+// EndTurn completes the turn for streaming outputs, and renders the full
+// message to the rest.
+func (f *Fanout) EndTurn(m Message) {
+	f.each(func(s *sink) {
+		switch style := s.style.(type) {
+		case Streamer:
+			style.EndTurn(m)
+		default:
+			if m.Content != "" {
+				style.Render(m)
+			}
+		}
+	})
+}
+
+func (f *Fanout) Summary(text string) {
+	f.each(func(s *sink) { s.style.Summary(text) })
+}
+
+func (f *Fanout) Reveal(r Reveal) {
+	f.each(func(s *sink) { s.style.Reveal(r) })
+}
+
+// Close closes every output, including those that failed, returning their
+// errors together.
+func (f *Fanout) Close() error {
+	var errs []error
+	for _, s := range f.sinks {
+		// Closing may write a footer, so check for failed writes after it
+		err := errors.Join(s.style.Close(), s.w.err)
+		if s.file != nil {
+			err = errors.Join(err, s.file.Close())
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestFanoutDropsFailingOutput(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full to fail writes")
+	}
+
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+
+	f, err := Open([]string{"plain", "jsonl=/dev/full", "markdown=" + filepath.Join(dir, "out.md")}, stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Render(Message{Name: "Ann", Content: "Hello."})
+	f.Render(Message{Name: "Bob", Content: "Hi."})
+	f.Summary("Dialogue ended")
+
+	err = f.Close()
+	if !errors.Is(err, syscall.ENOSPC) || !strings.Contains(err.Error(), "jsonl=/dev/full") {
+		t.Errorf("Close() = %v, want the jsonl output's write error", err)
+	}
+
+	for _, name := range []string{"stdout", "out.md"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "Hi.") || !strings.Contains(string(data), "Dialogue ended") {
+			t.Errorf("%s stopped with the failing output:\n%s", name, data)
+		}
+	}
+}
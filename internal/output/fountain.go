@@ -0,0 +1,158 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fountain renders the dialogue as a screenplay in the Fountain markup
+// language (https://fountain.io). Stage directions that the model wraps in
+// *asterisks* become parentheticals within a speech, or action lines when
+// they stand on their own.
+type Fountain struct {
+	W io.Writer
+}
+
+// Begin writes the title page.
+func (f Fountain) Begin(t Title) {
+	fmt.Fprintf(f.W, "Title: %s\n", t.Title)
+	var cast []string
+	for _, member := range t.Cast {
+		if !member.Moderator {
+			cast = append(cast, member.Name)
+		}
+	}
+	if len(cast) > 0 {
+		fmt.Fprintf(f.W, "Credit: Performed by\nAuthor: %s\n", strings.Join(cast, ", "))
+	}
+	if scenario := strings.TrimSpace(t.Scenario); scenario != "" {
+		fmt.Fprintln(f.W, "Notes:")
+		for _, line := range strings.Split(scenario, "\n") {
+			// a blank line would end the title page
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(f.W, "    %s\n", line)
+			}
+		}
+	}
+	fmt.Fprint(f.W, "\n===\n\n")
+}
+
+func (f Fountain) Render(m Message) {
+	segments := splitDirections(m.Content)
+
+	if m.Moderator {
+		// Narration is the scene's action
+		for _, s := range segments {
+			f.action(s.text)
+		}
+		return
+	}
+
+	// Directions after the last line of speech follow the dialogue as action
+	last := len(segments)
+	for last > 0 && segments[last-1].direction {
+		last--
+	}
+
+	if last > 0 {
+		fmt.Fprintln(f.W, strings.ToUpper(m.Name))
+		for _, s := range segments[:last] {
+			if s.direction {
+				fmt.Fprintf(f.W, "(%s)\n", s.text)
+			} else {
+				fmt.Fprintln(f.W, s.text)
+			}
+		}
+		fmt.Fprintln(f.W)
+	}
+
+	for _, s := range segments[last:] {
+		f.action(subject(m.Name, s.text))
+	}
+}
+
+// subject names the speaker in a direction that leaves them implicit, so
+// "*trips over a rope*" becomes "Eddie trips over a rope".
+func subject(name, direction string) string {
+	if r, _ := utf8.DecodeRuneInString(direction); unicode.IsLower(r) {
+		return name + " " + direction
+	}
+	return direction
+}
+
+// action writes text as an action paragraph, forced with "!" so that lines
+// in capitals aren't taken for character cues.
+func (f Fountain) action(text string) {
+	fmt.Fprintf(f.W, "!%s\n\n", text)
+}
+
+func (f Fountain) Summary(text string) {
+	fmt.Fprintf(f.W, "[[%s]]\n\n", strings.TrimSpace(text))
+}
+
+func (f Fountain) Reveal(r Reveal) {
+	f.action(fmt.Sprintf("%s's secret: %s", r.Name, r.Secret))
+	if r.Verdict != "" {
+		fmt.Fprintf(f.W, "[[%s]]\n\n", r.Verdict)
+	}
+}
+
+func (f Fountain) Close() error {
+	return nil
+}
+
+// segment is a run of speech or a stage direction within a message.
+type segment struct {
+	text      string
+	direction bool
+}
+
+// splitDirections separates *stage directions* from the speech around them.
+// Doubled asterisks are **bold** rather than directions, and are kept as they
+// are. Blank lines are dropped, since they would end a Fountain speech.
+func splitDirections(content string) []segment {
+	var (
+		segments []segment
+		speech   strings.Builder
+	)
+
+	flush := func() {
+		for _, line := range strings.Split(speech.String(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				segments = append(segments, segment{text: line})
+			}
+		}
+		speech.Reset()
+	}
+
+	for i := 0; i < len(content); i++ {
+		if content[i] != '*' {
+			speech.WriteByte(content[i])
+			continue
+		}
+		if strings.HasPrefix(content[i:], "**") {
+			speech.WriteString("**")
+			i++
+			continue
+		}
+
+		// A direction runs to the next single asterisk on the same line
+		end := strings.IndexAny(content[i+1:], "*\n")
+		if end <= 0 || content[i+1+end] != '*' || strings.HasPrefix(content[i+1+end:], "**") {
+			speech.WriteByte('*')
+			continue
+		}
+
+		flush()
+		if direction := strings.TrimSpace(content[i+1 : i+1+end]); direction != "" {
+			segments = append(segments, segment{text: direction, direction: true})
+		}
+		i += end + 1
+	}
+	flush()
+
+	return segments
+}
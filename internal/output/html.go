@@ -0,0 +1,151 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// personaColours are given to personas by seat, wrapping for larger casts.
+var personaColours = []string{"#dbeafe", "#fce7f3", "#dcfce7", "#fef3c7", "#ede9fe", "#ffedd5", "#cffafe", "#f1f5f9"}
+
+var htmlTemplates = template.Must(template.New("html").Funcs(template.FuncMap{
+	"colour": func(seat int) string { return personaColours[seat%len(personaColours)] },
+	"meta":   messageMeta,
+	// odd seats speak from the right, as in a chat between two people
+	"right": func(seat int) bool { return seat%2 == 1 },
+}).Parse(`
+{{- define "header" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #1f2937; background: #fff; }
+h1 { font-size: 1.5rem; }
+details { background: #f9fafb; border: 1px solid #e5e7eb; border-radius: .5rem; padding: .5rem 1rem; margin-bottom: 2rem; }
+summary { cursor: pointer; font-weight: 600; }
+dt { font-weight: 600; margin-top: .75rem; }
+dd, .scenario { margin: .25rem 0 0; white-space: pre-wrap; }
+.message { display: flex; flex-direction: column; margin: .75rem 0; }
+.message.right { align-items: flex-end; }
+.message.moderator { align-items: center; }
+.name { font-size: .8rem; font-weight: 600; color: #6b7280; margin: 0 .75rem .2rem; }
+.bubble { max-width: 80%; padding: .6rem .9rem; border-radius: 1rem; white-space: pre-wrap; }
+.moderator .bubble { background: none; font-style: italic; color: #4b5563; text-align: center; }
+.summary { color: #6b7280; font-size: .85rem; margin: .25rem 0; white-space: pre-wrap; }
+.reveal { border-top: 1px solid #e5e7eb; margin-top: 1rem; padding-top: .5rem; }
+.verdict { color: #6b7280; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<details>
+<summary>Scenario and cast</summary>
+<p class="scenario">{{.Scenario}}</p>
+<dl>
+{{- range .Cast}}
+<dt>{{.Name}}{{if .Moderator}} (moderator){{end}}</dt>
+<dd>{{.Persona}}</dd>
+{{- end}}
+</dl>
+</details>
+<main>
+{{end -}}
+
+{{- define "message" -}}
+{{- if .Moderator -}}
+<div class="message moderator" title="{{meta .}}">
+<div class="bubble">{{.Name}}: {{.Content}}</div>
+</div>
+{{else -}}
+<div class="message{{if right .Persona}} right{{end}}" title="{{meta .}}">
+<div class="name">{{.Name}}</div>
+<div class="bubble" style="background: {{colour .Persona}}">{{.Content}}</div>
+</div>
+{{end -}}
+{{- end -}}
+
+{{- define "summary" -}}
+<p class="summary">{{.}}</p>
+{{end -}}
+
+{{- define "reveal" -}}
+<div class="reveal">
+<p><strong>{{.Name}}'s secret:</strong> {{.Secret}}</p>
+{{- if .Verdict}}
+<p class="verdict">{{.Verdict}}</p>
+{{- end}}
+</div>
+{{end -}}
+
+{{- define "footer" -}}
+</main>
+</body>
+</html>
+{{end -}}
+`))
+
+// messageMeta summarises a message's model and metrics for its tooltip.
+func messageMeta(m Message) string {
+	var meta []string
+	if m.Model != "" {
+		meta = append(meta, m.Model)
+	}
+	if m.Metrics.Tokens > 0 {
+		meta = append(meta, fmt.Sprintf("%d tokens", m.Metrics.Tokens))
+	}
+	if m.Metrics.Latency > 0 {
+		meta = append(meta, m.Metrics.Latency.Round(time.Millisecond).String())
+	}
+	if !m.Time.IsZero() {
+		meta = append(meta, m.Time.Format(time.DateTime))
+	}
+	return strings.Join(meta, " · ")
+}
+
+// HTML renders the dialogue as a standalone HTML page of chat bubbles, one
+// colour per persona. Each part is written as soon as it is known, so an
+// interrupted run still leaves a readable page.
+type HTML struct {
+	W io.Writer
+
+	started bool
+}
+
+// execute renders the named template.
+func (h *HTML) execute(name string, data any) {
+	htmlTemplates.ExecuteTemplate(h.W, name, data)
+}
+
+// Begin writes the document head and the collapsible scenario header.
+func (h *HTML) Begin(t Title) {
+	h.started = true
+	h.execute("header", t)
+}
+
+func (h *HTML) Render(m Message) {
+	if !h.started {
+		h.Begin(Title{Title: "Dialogue"})
+	}
+	h.execute("message", m)
+}
+
+func (h *HTML) Summary(text string) {
+	h.execute("summary", strings.TrimSpace(text))
+}
+
+func (h *HTML) Reveal(r Reveal) {
+	h.execute("reveal", r)
+}
+
+func (h *HTML) Close() error {
+	if h.started {
+		h.execute("footer", nil)
+	}
+	return nil
+}
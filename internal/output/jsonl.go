@@ -0,0 +1,76 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// JSONL writes one JSON object per line: a "message" for each utterance,
+// followed by "summary" and "reveal" objects as the dialogue ends.
+type JSONL struct {
+	encoder *json.Encoder
+}
+
+func NewJSONL(w io.Writer) *JSONL {
+	return &JSONL{encoder: json.NewEncoder(w)}
+}
+
+type jsonlMessage struct {
+	Type           string    `json:"type"`
+	Turn           int       `json:"turn"`
+	Persona        int       `json:"persona"`
+	Speaker        string    `json:"speaker"`
+	Moderator      bool      `json:"moderator,omitempty"`
+	Model          string    `json:"model,omitempty"`
+	Content        string    `json:"content"`
+	Started        time.Time `json:"started"`
+	Time           time.Time `json:"time"`
+	PromptTokens   int       `json:"prompt_tokens,omitempty"`
+	Tokens         int       `json:"tokens,omitempty"`
+	EvalSeconds    float64   `json:"eval_seconds,omitempty"`
+	LatencySeconds float64   `json:"latency_seconds,omitempty"`
+}
+
+type jsonlSummary struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type jsonlReveal struct {
+	Type    string `json:"type"`
+	Speaker string `json:"speaker"`
+	Secret  string `json:"secret"`
+	Verdict string `json:"verdict,omitempty"`
+}
+
+func (j *JSONL) Render(m Message) {
+	j.encoder.Encode(jsonlMessage{
+		Type:           "message",
+		Turn:           m.Turn,
+		Persona:        m.Persona,
+		Speaker:        m.Name,
+		Moderator:      m.Moderator,
+		Model:          m.Model,
+		Content:        m.Content,
+		Started:        m.Time.Add(-m.Metrics.Latency),
+		Time:           m.Time,
+		PromptTokens:   m.Metrics.PromptTokens,
+		Tokens:         m.Metrics.Tokens,
+		EvalSeconds:    m.Metrics.EvalDuration.Seconds(),
+		LatencySeconds: m.Metrics.Latency.Seconds(),
+	})
+}
+
+func (j *JSONL) Summary(text string) {
+	j.encoder.Encode(jsonlSummary{Type: "summary", Text: strings.TrimSpace(text)})
+}
+
+func (j *JSONL) Reveal(r Reveal) {
+	j.encoder.Encode(jsonlReveal{Type: "reveal", Speaker: r.Name, Secret: r.Secret, Verdict: r.Verdict})
+}
+
+func (j *JSONL) Close() error {
+	return nil
+}
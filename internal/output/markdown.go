@@ -0,0 +1,50 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Markdown renders the dialogue as a Markdown document, one paragraph per
+// message.
+type Markdown struct {
+	W io.Writer
+}
+
+func (md Markdown) Render(m Message) {
+	md.StartTurn(m)
+	md.Delta(m.Content)
+	md.EndTurn(m)
+}
+
+func (md Markdown) StartTurn(m Message) {
+	if m.Moderator {
+		fmt.Fprintf(md.W, "*%s (moderator):* ", m.Name)
+		return
+	}
+	fmt.Fprintf(md.W, "**%s:** ", m.Name)
+}
+
+func (md Markdown) Delta(words string) {
+	fmt.Fprint(md.W, words)
+}
+
+func (md Markdown) EndTurn(Message) {
+	fmt.Fprint(md.W, "\n\n")
+}
+
+func (md Markdown) Summary(text string) {
+	fmt.Fprintf(md.W, "_%s_\n\n", strings.TrimSpace(text))
+}
+
+func (md Markdown) Reveal(r Reveal) {
+	fmt.Fprintf(md.W, "**%s's secret:** %s\n\n", r.Name, r.Secret)
+	if r.Verdict != "" {
+		fmt.Fprintf(md.W, "> %s\n\n", strings.ReplaceAll(r.Verdict, "\n", "\n> "))
+	}
+}
+
+func (md Markdown) Close() error {
+	return nil
+}
@@ -1,13 +1,194 @@
 package output
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
 
+// Message is a single utterance in the dialogue.
+type Message struct {
+	Name    string
+	Content string
+	// Moderator marks narration from the scenario moderator rather than a participant
+	Moderator bool
+
+	// Turn is the message's position in the dialogue, counting from 0
+	Turn int
+	// Persona is the speaker's seat, counting from 0, or -1 for the moderator
+	Persona int
+	Model   string
+	// Time is when the message was complete
+	Time    time.Time
+	Metrics Metrics
+	// WordsPerMinute is the speaker's reading pace for timed outputs; zero uses a default
+	WordsPerMinute int
+}
+
+// Metrics are the backend's measurements of a model's turn.
+type Metrics struct {
+	PromptTokens int
+	Tokens       int
+	EvalDuration time.Duration
+	// Latency is the wall-clock time from request to complete response
+	Latency time.Duration
+}
+
+// Reveal discloses a persona's secret objective once the dialogue is over.
+type Reveal struct {
+	Name   string
+	Secret string
+	// Verdict is the judge's view of whether the secret was achieved, if asked
+	Verdict string
+}
+
+// OutputStyle renders the dialogue in one format. Styles ignore write errors,
+// leaving them to the Fanout returned by Open, which drops a failing output.
 type OutputStyle interface {
-	Render(name, words string)
+	Render(m Message)
+	Summary(text string)
+	Reveal(r Reveal)
+	// Close completes the output once the dialogue is over, however it ended
+	Close() error
 }
 
-type Text struct{}
+// Streamer is implemented by output styles that can render a message
+// incrementally as it is generated. Output styles that do not implement it
+// receive the full message via Render at the end of each turn.
+type Streamer interface {
+	StartTurn(m Message)
+	Delta(words string)
+	// EndTurn completes the turn with the full message, which has no content
+	// if the turn was abandoned part way through
+	EndTurn(m Message)
+}
+
+// Title describes the dialogue as a whole.
+type Title struct {
+	Title    string
+	Cast     []CastMember
+	Scenario string
+}
+
+// CastMember is a persona taking part in the dialogue.
+type CastMember struct {
+	Name    string
+	Persona string
+	// Moderator marks the scenario moderator rather than a participant
+	Moderator bool
+}
+
+// Titled is implemented by output styles that open with a title page or
+// document header. Begin is called once, before the first message.
+type Titled interface {
+	Begin(t Title)
+}
+
+// Formats are the names accepted by New and Open.
+var Formats = []string{"text", "plain", "markdown", "jsonl", "fountain", "html", "srt", "vtt"}
+
+// New returns the output style for format, writing to w. The text format
+// uses ANSI styling only when w is a terminal and NO_COLOR is unset.
+func New(format string, w io.Writer) (OutputStyle, error) {
+	return newStyle(format, w, colorEnabled(w))
+}
+
+// newStyle returns the output style for format, writing to w, with ANSI
+// styling for the text format if color is set.
+func newStyle(format string, w io.Writer, color bool) (OutputStyle, error) {
+	switch format {
+	case "", "text":
+		return Text{W: w, Color: color}, nil
+	case "plain":
+		return Text{W: w}, nil
+	case "markdown":
+		return Markdown{W: w}, nil
+	case "jsonl":
+		return NewJSONL(w), nil
+	case "fountain":
+		return Fountain{W: w}, nil
+	case "html":
+		return &HTML{W: w}, nil
+	case "srt":
+		return &Subtitles{W: w}, nil
+	case "vtt":
+		return &Subtitles{W: w, VTT: true}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (expected one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// colorEnabled reports whether ANSI styling suits w: a terminal, without the
+// NO_COLOR convention set.
+func colorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Text renders the dialogue as a script, styled with ANSI escapes when Color
+// is set.
+type Text struct {
+	W     io.Writer
+	Color bool
+}
+
+// style wraps s in the ANSI escape sequence code, if colour is enabled.
+func (t Text) style(code, s string) string {
+	if !t.Color {
+		return s
+	}
+	return "\033[" + code + "m" + s + "\033[0m"
+}
+
+func (t Text) Render(m Message) {
+	t.StartTurn(m)
+	t.Delta(m.Content)
+	t.EndTurn(m)
+}
+
+func (t Text) StartTurn(m Message) {
+	if m.Moderator {
+		// Narration is set apart in italics
+		if t.Color {
+			fmt.Fprintf(t.W, "\033[1;3m[%s]\033[0;3m ", m.Name)
+		} else {
+			fmt.Fprintf(t.W, "[%s] ", m.Name)
+		}
+		return
+	}
+	fmt.Fprintf(t.W, "%s: ", t.style("1", m.Name))
+}
+
+func (t Text) Delta(words string) {
+	fmt.Fprint(t.W, words)
+}
+
+func (t Text) EndTurn(Message) {
+	if t.Color {
+		fmt.Fprint(t.W, "\033[0m")
+	}
+	fmt.Fprint(t.W, "\n\n")
+}
+
+func (t Text) Summary(text string) {
+	fmt.Fprintln(t.W, t.style("2", text))
+}
+
+func (t Text) Reveal(r Reveal) {
+	fmt.Fprintf(t.W, "%s: %s\n", t.style("1", r.Name+"'s secret"), r.Secret)
+	if r.Verdict != "" {
+		fmt.Fprintln(t.W, t.style("2", r.Verdict))
+	}
+}
 
-func (t Text) Render(name, words string) {
-	fmt.Printf("\033[1m%s\033[0m: %s\n\n", name, words)
+func (t Text) Close() error {
+	return nil
 }
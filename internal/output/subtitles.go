@@ -0,0 +1,157 @@
+package output
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultWordsPerMinute is a comfortable speaking pace for text to speech
+	defaultWordsPerMinute = 150
+	// maxCueLength is the most characters shown at once: two lines of 42
+	maxCueLength = 84
+	// minCueDuration keeps the briefest cues on screen long enough to read
+	minCueDuration = time.Second
+)
+
+// Subtitles renders the dialogue as timed subtitle cues in SRT, or WebVTT
+// when VTT is set. Each message is read at its persona's words per minute and
+// split into cues short enough to read, labelled with the speaker.
+type Subtitles struct {
+	W   io.Writer
+	VTT bool
+
+	cursor  time.Duration
+	cues    int
+	started bool
+}
+
+// printf writes to W, opening with the WebVTT header.
+func (s *Subtitles) printf(format string, args ...any) {
+	if !s.started && s.VTT {
+		s.started = true
+		s.printf("WEBVTT\n\n")
+	}
+	fmt.Fprintf(s.W, format, args...)
+}
+
+func (s *Subtitles) Render(m Message) {
+	wpm := cmp.Or(m.WordsPerMinute, defaultWordsPerMinute)
+
+	for _, cue := range splitCues(m.Content) {
+		duration := max(minCueDuration, time.Duration(len(strings.Fields(cue)))*time.Minute/time.Duration(wpm))
+		start, end := s.cursor, s.cursor+duration
+		s.cursor = end
+		s.cues++
+
+		if s.VTT {
+			s.printf("%d\n%s --> %s\n<v %s>%s\n\n", s.cues, s.timestamp(start), s.timestamp(end), vttEscape(m.Name), vttEscape(wrapCue(cue)))
+		} else {
+			s.printf("%d\n%s --> %s\n%s: %s\n\n", s.cues, s.timestamp(start), s.timestamp(end), m.Name, wrapCue(cue))
+		}
+	}
+}
+
+// timestamp formats d as HH:MM:SS,mmm for SRT or HH:MM:SS.mmm for WebVTT.
+func (s *Subtitles) timestamp(d time.Duration) string {
+	separator := ","
+	if s.VTT {
+		separator = "."
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d",
+		int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, separator, d.Milliseconds()%1000)
+}
+
+// Summary is kept as a comment in WebVTT; SRT has no place for it.
+func (s *Subtitles) Summary(text string) {
+	if s.VTT {
+		s.printf("NOTE %s\n\n", vttNote(text))
+	}
+}
+
+// Reveal is kept as a comment in WebVTT; SRT has no place for it.
+func (s *Subtitles) Reveal(r Reveal) {
+	if s.VTT {
+		s.printf("NOTE %s\n\n", vttNote(r.Name+"'s secret: "+r.Secret+"\n"+r.Verdict))
+	}
+}
+
+func (s *Subtitles) Close() error {
+	return nil
+}
+
+// vttEscaper escapes the characters WebVTT reserves in cue text. Escaping ">"
+// also breaks up any "-->", which would otherwise be read as a timing line.
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// vttEscape makes text safe to use as WebVTT cue text or a voice name.
+func vttEscape(text string) string {
+	return vttEscaper.Replace(text)
+}
+
+// vttNote makes text safe for a WebVTT comment, which may contain neither
+// "-->" nor a blank line.
+func vttNote(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "-->", "- ->")
+	for strings.Contains(text, "\n\n") {
+		text = strings.ReplaceAll(text, "\n\n", "\n")
+	}
+	return text
+}
+
+// splitCues breaks content into cues of at most maxCueLength characters,
+// starting a new cue after each sentence once the current one is a third full.
+func splitCues(content string) []string {
+	var (
+		cues []string
+		cue  []string
+		size int
+	)
+
+	for _, word := range strings.Fields(content) {
+		if size > 0 && size+1+len(word) > maxCueLength {
+			cues, cue, size = append(cues, strings.Join(cue, " ")), nil, 0
+		}
+		cue = append(cue, word)
+		size += len(word) + min(size, 1)
+
+		if size >= maxCueLength/3 && strings.ContainsAny(word[len(word)-1:], ".!?") {
+			cues, cue, size = append(cues, strings.Join(cue, " ")), nil, 0
+		}
+	}
+
+	if len(cue) > 0 {
+		cues = append(cues, strings.Join(cue, " "))
+	}
+
+	return cues
+}
+
+// wrapCue splits a long cue over two lines at the space nearest its middle.
+func wrapCue(cue string) string {
+	if len(cue) <= maxCueLength/2 {
+		return cue
+	}
+
+	middle, split := len(cue)/2, -1
+	for i := range len(cue) {
+		if cue[i] == ' ' && (split < 0 || abs(i-middle) < abs(split-middle)) {
+			split = i
+		}
+	}
+	if split < 0 {
+		return cue
+	}
+
+	return cue[:split] + "\n" + cue[split+1:]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,25 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVTTEscapesCueText(t *testing.T) {
+	var b strings.Builder
+	s := &Subtitles{W: &b, VTT: true}
+
+	s.Render(Message{Name: "R&D <Bot>", Content: "Fish & chips --> <b>now</b>"})
+	s.Summary("a --> b\n\nc")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "WEBVTT\n\n" +
+		"1\n00:00:00.000 --> 00:00:02.000\n" +
+		"<v R&amp;D &lt;Bot&gt;>Fish &amp; chips --&gt; &lt;b&gt;now&lt;/b&gt;\n\n" +
+		"NOTE a - -> b\nc\n\n"
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -8,13 +8,20 @@ import (
 )
 
 type Persona struct {
-	Model   string               `mapstructure:"model" default:"gemma3"`
-	Name    string               `mapstructure:"name"`
-	Persona string               `mapstructure:"persona"`
-	Prompts []string             `mapstructure:"prompts"`
-	Options options.ModelOptions `mapstructure:"options"`
+	Model   string                 `mapstructure:"model" yaml:"model" default:"gemma3"`
+	Name    string                 `mapstructure:"name" yaml:"name"`
+	Persona string                 `mapstructure:"persona" yaml:"persona"`
+	Prompts []string               `mapstructure:"prompts" yaml:"prompts,omitempty"`
+	Options options.ModelOptions   `mapstructure:"options" yaml:"options"`
+	Human   bool                   `mapstructure:"human" yaml:"human,omitempty"`
+	Backend options.BackendOptions `mapstructure:"backend" yaml:"backend,omitempty"`
+	// WordsPerMinute paces the persona's lines in subtitle output
+	WordsPerMinute int `mapstructure:"wpm" yaml:"wpm,omitempty"`
 }
 
+// HumanRef is the persona reference that seats the user in the dialogue.
+const HumanRef = "@human"
+
 func (p *Persona) LoadFromFile(filePath string) error {
 	// Use library.ReadFileOrPath which tries:
 	// 1. Local filesystem at the given path
@@ -4,12 +4,46 @@ import (
 	"go.yaml.in/yaml/v4"
 
 	"github.com/isometry/yaketty/internal/library"
+	"github.com/isometry/yaketty/internal/limits"
+	"github.com/isometry/yaketty/internal/persona"
 )
 
 type Scenario struct {
-	Scenario      string    `mapstructure:"scenario"`
-	Roles         [2]string `mapstructure:"roles"`
-	OpeningPrompt string    `mapstructure:"opening_prompt" default:"Start the conversation with an appropriate greeting or opening statement for this scenario"`
+	Scenario      string        `mapstructure:"scenario" yaml:"scenario"`
+	Roles         []string      `mapstructure:"roles" yaml:"roles"`
+	OpeningPrompt string        `mapstructure:"opening_prompt" yaml:"opening_prompt" default:"Start the conversation with an appropriate greeting or opening statement for this scenario"`
+	Limits        limits.Limits `mapstructure:"limits" yaml:"limits"`
+	TurnOrder     TurnOrder     `mapstructure:"turn_order" yaml:"turn_order"`
+	Moderator     *Moderator    `mapstructure:"moderator" yaml:"moderator,omitempty"`
+	Secrets       []string      `mapstructure:"secrets" yaml:"secrets,omitempty"`
+	Reveal        Reveal        `mapstructure:"reveal" yaml:"reveal,omitempty"`
+}
+
+// Reveal configures how secrets are disclosed once the dialogue ends.
+type Reveal struct {
+	// Judge asks a model whether each persona achieved its secret objective
+	Judge bool `mapstructure:"judge" yaml:"judge,omitempty"`
+	// Model judges the secrets; empty uses the judging persona's own model
+	Model string `mapstructure:"model" yaml:"model,omitempty"`
+}
+
+// Moderator is an optional narrator or game master who interjects between
+// the participants' turns.
+type Moderator struct {
+	persona.Persona `mapstructure:",squash" yaml:",inline"`
+
+	// Interval interjects after every Interval participant turns
+	Interval int `mapstructure:"interval" yaml:"interval,omitempty"`
+	// Keywords interjects whenever a participant mentions one of them
+	Keywords []string `mapstructure:"keywords" yaml:"keywords,omitempty"`
+}
+
+// TurnOrder selects and configures the strategy that decides who speaks next.
+type TurnOrder struct {
+	Strategy string    `mapstructure:"strategy" yaml:"strategy" default:"round-robin"`
+	Weights  []float64 `mapstructure:"weights" yaml:"weights,omitempty"`
+	Model    string    `mapstructure:"model" yaml:"model,omitempty"`
+	Seed     uint64    `mapstructure:"seed" yaml:"seed,omitempty"`
 }
 
 func (s *Scenario) LoadFromFile(filePath string) error {
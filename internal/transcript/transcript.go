@@ -0,0 +1,60 @@
+package transcript
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+
+	"github.com/isometry/yaketty/internal/config"
+	"github.com/isometry/yaketty/internal/utils"
+)
+
+// Transcript is a persisted dialogue: the fully resolved configuration plus
+// every message spoken so far.
+type Transcript struct {
+	Config   config.Config `yaml:"config"`
+	Messages []Entry       `yaml:"messages"`
+}
+
+// Entry is a single message in a transcript.
+type Entry struct {
+	Persona int       `yaml:"persona"`
+	Speaker string    `yaml:"speaker"`
+	Model   string    `yaml:"model"`
+	Time    time.Time `yaml:"time"`
+	Content string    `yaml:"content"`
+
+	// Metrics reported by the backend, absent for human turns
+	PromptTokens int           `yaml:"prompt_tokens,omitempty"`
+	Tokens       int           `yaml:"tokens,omitempty"`
+	EvalDuration time.Duration `yaml:"eval_duration,omitempty"`
+	Latency      time.Duration `yaml:"latency,omitempty"`
+}
+
+// Load reads a transcript from filePath.
+func Load(filePath string) (*Transcript, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var t Transcript
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("error parsing transcript %s: %w", filePath, err)
+	}
+
+	return &t, nil
+}
+
+// Save writes the transcript to filePath, replacing any existing file
+// atomically so an interrupted write never leaves a truncated transcript.
+func (t *Transcript) Save(filePath string) error {
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomic(filePath, data)
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -19,3 +20,31 @@ func IsFilePath(filePath string) bool {
 	slog.Debug("path exists", slog.String("path", filePath), slog.Bool("isDir", fileInfo.IsDir()))
 	return !fileInfo.IsDir()
 }
+
+// WriteFileAtomic replaces the file at filePath with data, writing to a
+// temporary file alongside it first so an interrupted write never leaves a
+// truncated file.
+func WriteFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".yaketty-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// CreateTemp makes the file private; give it the usual permissions
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/isometry/yaketty/cmd"
+	"github.com/isometry/yaketty/internal/dialogue"
 )
 
 var (
@@ -13,11 +18,26 @@ var (
 	date    = "unknown"
 )
 
+// exitInterrupted is the conventional exit status after SIGINT.
+const exitInterrupted = 130
+
 func main() {
 	versionString := fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, date)
 	rootCmd := cmd.New(versionString)
 
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		// After the first signal, restore the default handling so a second one forces exit
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if errors.Is(err, dialogue.ErrInterrupted) {
+			os.Exit(exitInterrupted)
+		}
 		os.Exit(1)
 	}
 }